@@ -34,6 +34,66 @@ func (r *Registry) Delete(gclosure unsafe.Pointer) {
 	r.reg.Delete(gclosure)
 }
 
+// Range calls f for each registered GClosure callback until f returns false. f
+// may delete the GClosure that it's given.
+func (r *Registry) Range(f func(gclosure unsafe.Pointer, callback *FuncStack) bool) {
+	r.reg.Range(func(k, v interface{}) bool {
+		return f(k.(unsafe.Pointer), v.(*FuncStack))
+	})
+}
+
+// Arena is a slice-backed store of callbacks indexed by small integers. Adding
+// to and removing from it is cheaper than with a Registry, but callbacks can
+// only be found by their index. Each object has an Arena of its own, so its
+// lock is only contended by callers using the same object.
+type Arena struct {
+	mu    sync.Mutex
+	funcs []*FuncStack
+	free  []int
+}
+
+// Add adds the given callback and returns its index. Indices of deleted
+// callbacks are reused.
+func (a *Arena) Add(callback *FuncStack) int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if n := len(a.free); n > 0 {
+		i := a.free[n-1]
+		a.free = a.free[:n-1]
+		a.funcs[i] = callback
+		return i
+	}
+
+	a.funcs = append(a.funcs, callback)
+	return len(a.funcs) - 1
+}
+
+// Load loads the callback at the given index. Nil is returned if it's not
+// found.
+func (a *Arena) Load(i int) *FuncStack {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if i < 0 || i >= len(a.funcs) {
+		return nil
+	}
+	return a.funcs[i]
+}
+
+// Delete deletes the callback at the given index.
+func (a *Arena) Delete(i int) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if i < 0 || i >= len(a.funcs) || a.funcs[i] == nil {
+		return
+	}
+
+	a.funcs[i] = nil
+	a.free = append(a.free, i)
+}
+
 /*
 var (
 	closures = sync.Map{} // unsafe.Pointer(*GClosure) -> reflect.Value
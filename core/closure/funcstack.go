@@ -2,6 +2,7 @@ package closure
 
 import (
 	"fmt"
+	"log"
 	"reflect"
 	"runtime"
 	"strconv"
@@ -92,8 +93,19 @@ const headerSignature = "closure error: "
 
 // Panicf panics with the given FuncStack printed to standard error.
 func (fs *FuncStack) Panicf(msgf string, v ...interface{}) {
+	panic(fs.sprintf(headerSignature, msgf, v...))
+}
+
+// Warnf logs a warning with the given FuncStack using the standard logger.
+func (fs *FuncStack) Warnf(msgf string, v ...interface{}) {
+	log.Print(fs.sprintf("closure warning: ", msgf, v...))
+}
+
+// sprintf formats the message with the given header followed by the trace of
+// where the closure was added.
+func (fs *FuncStack) sprintf(header, msgf string, v ...interface{}) string {
 	msg := strings.Builder{}
-	msg.WriteString(headerSignature)
+	msg.WriteString(header)
 	fmt.Fprintf(&msg, msgf, v...)
 
 	msg.WriteString("\n\nClosure added at:")
@@ -113,7 +125,7 @@ func (fs *FuncStack) Panicf(msgf string, v ...interface{}) {
 		}
 	}
 
-	panic(msg.String())
+	return msg.String()
 }
 
 // TryRepanic attempts to recover a panic. If successful, it will re-panic with
@@ -1,4 +1,19 @@
 // Package intern implements value interning for Cgo sharing.
+//
+// Each GObject wrapped by Go has a Box holding its closures, and Go holds a
+// toggle reference on the GObject itself. The Box is kept in one of two tables
+// depending on who else references the object:
+//
+//   - While Go holds the only reference, the Box is in the weak table, which
+//     doesn't keep it alive. Once the Go wrapper is collected, ShouldFree
+//     clears the Box and lets the toggle reference go.
+//   - Once C code takes another reference, the toggle notify calls MakeStrong
+//     to move the Box to the strong table. The Go wrapper may then be
+//     collected, but ShouldFree refuses to free the Box, so that closures
+//     called from C keep working.
+//
+// When the other references are dropped again, the toggle notify calls
+// MakeWeak to move the Box back to the weak table.
 package intern
 
 import (
@@ -15,6 +30,9 @@ import (
 // Box contains possible interned values for each GObject.
 type Box struct {
 	Closures closure.Registry
+	// Arena holds the callbacks of closures that are found by index rather
+	// than by their GClosure pointer.
+	Arena closure.Arena
 }
 
 // newBox creates a zero-value instance of Box.
@@ -68,6 +86,90 @@ func RemoveClosure(gobject, gclosure unsafe.Pointer) {
 	// will have already handled it.
 }
 
+// ObjectArenaClosure gets the FuncStack at the given index of the Arena of the
+// given GObject pointer, which MUST be a C pointer.
+func ObjectArenaClosure(gobject unsafe.Pointer, index int) *closure.FuncStack {
+	shared.mu.RLock()
+	box, _ := gets(gobject)
+	shared.mu.RUnlock()
+
+	if box == nil {
+		return nil
+	}
+
+	return box.Arena.Load(index)
+}
+
+// RemoveArenaClosure removes the FuncStack at the given index of the Arena of
+// the given GObject pointer. Like with RemoveClosure, the box missing is fine.
+func RemoveArenaClosure(gobject unsafe.Pointer, index int) {
+	shared.mu.RLock()
+	box, _ := gets(gobject)
+	shared.mu.RUnlock()
+
+	if box != nil {
+		box.Arena.Delete(index)
+	}
+}
+
+// ReapClosures removes the GClosure callbacks that are stale and returns the
+// number of removed callbacks. hold is called with every object that has
+// callbacks while a lock keeps the object alive, so it must not call into this
+// package, such as through a toggle notify; it should only take a weak
+// reference. The function that it returns is then called without any lock
+// held with the object's closures, and returns those that are stale, so it may
+// make slow calls or call into this package.
+func ReapClosures(hold func(gobject unsafe.Pointer) (stale func(gclosures []unsafe.Pointer) []unsafe.Pointer)) int {
+	type objectClosures struct {
+		gobject  unsafe.Pointer
+		box      *Box
+		closures []unsafe.Pointer
+		stale    func([]unsafe.Pointer) []unsafe.Pointer
+	}
+
+	var objects []objectClosures
+	collect := func(gobject unsafe.Pointer, box *Box) {
+		var closures []unsafe.Pointer
+		box.Closures.Range(func(gclosure unsafe.Pointer, _ *closure.FuncStack) bool {
+			closures = append(closures, gclosure)
+			return true
+		})
+		if len(closures) > 0 {
+			objects = append(objects, objectClosures{gobject, box, closures, hold(gobject)})
+		}
+	}
+
+	shared.mu.RLock()
+	for gobject, box := range shared.strong {
+		collect(gobject, box)
+	}
+	for gobject, box := range shared.weak {
+		collect(gobject, (*Box)(unsafe.Pointer(box)))
+	}
+	shared.mu.RUnlock()
+
+	var n int
+	for _, object := range objects {
+		stale := object.stale(object.closures)
+		if len(stale) == 0 {
+			continue
+		}
+
+		// The box may have been freed or replaced meanwhile, in which case
+		// its closures are gone already.
+		shared.mu.Lock()
+		if box, _ := gets(object.gobject); box == object.box {
+			for _, gclosure := range stale {
+				box.Closures.Delete(gclosure)
+			}
+			n += len(stale)
+		}
+		shared.mu.Unlock()
+	}
+
+	return n
+}
+
 // ObjectBox gets the interned box for the given GObject C pointer. If the
 // object is new or unknown, then a new box is made.
 func ObjectBox(gobject unsafe.Pointer) *Box {
@@ -5,9 +5,16 @@ package glib
 // #include "glib.go.h"
 import "C"
 import (
+	"context"
+	"fmt"
 	"reflect"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
 	"unsafe"
 
+	"github.com/diamondburned/go-glib/core/callback"
 	"github.com/diamondburned/go-glib/core/closure"
 	"github.com/diamondburned/go-glib/core/intern"
 )
@@ -19,6 +26,126 @@ import (
 // SignalHandle is the ID of a signal handler.
 type SignalHandle uint
 
+// SignalHandler is a SignalHandle that remembers the object that it's
+// connected to, so that it can be disconnected, blocked and unblocked on its
+// own. The object is only referenced weakly, so holding a SignalHandler does
+// not keep it alive; once the object is finalized, all methods do nothing.
+type SignalHandler struct {
+	SignalHandle
+	weakRef *C.GWeakRef
+}
+
+// SignalHandler binds the given handle of a handler connected to v, such as
+// the one returned by Connect or ConnectAfter, to v.
+func (v *Object) SignalHandler(handle SignalHandle) *SignalHandler {
+	weakRef := (*C.GWeakRef)(C.g_malloc0(C.sizeof_GWeakRef))
+	C.g_weak_ref_init(weakRef, C.gpointer(v.native()))
+
+	h := &SignalHandler{SignalHandle: handle, weakRef: weakRef}
+	runtime.SetFinalizer(h, (*SignalHandler).free)
+	return h
+}
+
+// ConnectHandler is similar to Connect, except it returns a SignalHandler.
+func (v *Object) ConnectHandler(detailedSignal string, f interface{}) *SignalHandler {
+	return v.SignalHandler(v.connectClosure(false, detailedSignal, f))
+}
+
+func (h *SignalHandler) free() {
+	C.g_weak_ref_clear(h.weakRef)
+	C.g_free(C.gpointer(h.weakRef))
+}
+
+// withObject calls f with a strong reference to the object of h, unless it
+// has been finalized.
+func (h *SignalHandler) withObject(f func(obj C.gpointer)) {
+	obj := C.g_weak_ref_get(h.weakRef)
+	runtime.KeepAlive(h)
+	if obj == nil {
+		return
+	}
+	defer C.g_object_unref(obj)
+
+	f(obj)
+}
+
+// Disconnect disconnects the handler. It does nothing if the handler is
+// already disconnected.
+func (h *SignalHandler) Disconnect() {
+	h.withObject(func(obj C.gpointer) {
+		if gobool(C.g_signal_handler_is_connected(obj, C.gulong(h.SignalHandle))) {
+			C.g_signal_handler_disconnect(obj, C.gulong(h.SignalHandle))
+		}
+	})
+}
+
+// IsConnected returns true if the object of the handler is alive and the
+// handler is still connected to it.
+func (h *SignalHandler) IsConnected() bool {
+	var connected bool
+	h.withObject(func(obj C.gpointer) {
+		connected = gobool(C.g_signal_handler_is_connected(obj, C.gulong(h.SignalHandle)))
+	})
+	return connected
+}
+
+// Block is a wrapper around g_signal_handler_block(). It does nothing if the
+// handler is disconnected.
+func (h *SignalHandler) Block() {
+	h.withObject(func(obj C.gpointer) {
+		if gobool(C.g_signal_handler_is_connected(obj, C.gulong(h.SignalHandle))) {
+			C.g_signal_handler_block(obj, C.gulong(h.SignalHandle))
+		}
+	})
+}
+
+// Unblock is a wrapper around g_signal_handler_unblock(). It does nothing if
+// the handler is disconnected.
+func (h *SignalHandler) Unblock() {
+	h.withObject(func(obj C.gpointer) {
+		if gobool(C.g_signal_handler_is_connected(obj, C.gulong(h.SignalHandle))) {
+			C.g_signal_handler_unblock(obj, C.gulong(h.SignalHandle))
+		}
+	})
+}
+
+// DetailedSignal returns the detailed signal string "name::detail" for use with
+// Connect and Emit, or just name if detail is empty. It panics if name is not a
+// valid signal name, which must start with a letter followed by letters,
+// digits, dashes or underscores, or if detail contains whitespace or colons.
+func DetailedSignal(name, detail string) string {
+	if !isValidSignalName(name) {
+		panic(fmt.Sprintf("glib: invalid signal name %q", name))
+	}
+
+	if detail == "" {
+		return name
+	}
+
+	if strings.ContainsAny(detail, ": \t\n\r\v\f") {
+		panic(fmt.Sprintf("glib: invalid signal detail %q", detail))
+	}
+
+	return name + "::" + detail
+}
+
+func isValidSignalName(name string) bool {
+	if name == "" {
+		return false
+	}
+
+	for i, r := range name {
+		switch {
+		case 'a' <= r && r <= 'z', 'A' <= r && r <= 'Z':
+		case i > 0 && ('0' <= r && r <= '9' || r == '-' || r == '_'):
+		default:
+			return false
+		}
+	}
+
+	return true
+}
+
 // Connect is a wrapper around g_signal_connect_closure(). f must be a function
 // with at least one parameter matching the type it is connected to.
 //
@@ -29,6 +156,13 @@ type SignalHandle uint
 // the value may be packed in. If the type is not suitable, a runtime panic will
 // occur when the signal is emitted.
 //
+// As a special case, enum arguments may be received as a string parameter, in
+// which case f will receive the nick of the enum value instead of its integer.
+// Similarly, GPtrArray arguments containing GObjects may be received as a
+// []*Object parameter, GParamSpec arguments, such as the one of the "notify"
+// signal, as a *ParamSpec parameter, and boxed GValue arguments as a *Value
+// parameter.
+//
 // Circular References
 //
 // To prevent circular references, prefer declaring Connect functions like so:
@@ -76,6 +210,287 @@ func (v *Object) ConnectAfter(detailedSignal string, f interface{}) SignalHandle
 	return v.connectClosure(true, detailedSignal, f)
 }
 
+// ConnectMulti connects f to the given signal of every object in objs, as if
+// Connect was called on each of them. The handles are returned in the order of
+// objs. As usual, the first parameter of f receives the object emitting the
+// signal, which tells which of the objects it is.
+func ConnectMulti(objs []*Object, detailedSignal string, f interface{}) []SignalHandle {
+	handles := make([]SignalHandle, len(objs))
+	for i, obj := range objs {
+		handles[i] = obj.connectClosure(false, detailedSignal, f)
+	}
+	return handles
+}
+
+// ConnectNotify connects f to the "notify" signal of v, which is emitted for
+// every property change with the ParamSpec of the changed property. To only
+// be notified of a single property, use Connect with "notify::property-name".
+func (v *Object) ConnectNotify(f func(obj *Object, pspec *ParamSpec)) SignalHandle {
+	return v.connectClosure(false, "notify", f)
+}
+
+// ConnectRaw is similar to Connect, except f is given the GValues of the
+// signal's parameters as they are, starting with the instance, instead of Go
+// values. The returned Value, if not nil, is used as the return value of the
+// handler; it's converted to the return type of the signal if needed.
+//
+// The Values are only valid during the call to f, and must be copied if they
+// are needed afterwards.
+func (v *Object) ConnectRaw(detailedSignal string, f func(values []*Value) *Value) SignalHandle {
+	return v.connectClosure(false, detailedSignal, f)
+}
+
+// ConnectOnce is similar to Connect, except the handler disconnects itself the
+// first time that it's invoked, right before calling f. f is therefore called
+// at most once, even if the signal is emitted again from within f.
+func (v *Object) ConnectOnce(detailedSignal string, f interface{}) SignalHandle {
+	fs := closure.NewFuncStack(f, 1)
+	gobject := C.gpointer(v.native())
+
+	// handle is set before the signal can be emitted on the main loop. The
+	// object pointer is captured instead of v to not keep v alive.
+	var handle SignalHandle
+
+	once := reflect.MakeFunc(fs.Func.Type(), func(args []reflect.Value) []reflect.Value {
+		// Disconnecting from within the handler is fine, since GLib keeps the
+		// closure alive until the emission is done.
+		if gobool(C.g_signal_handler_is_connected(gobject, C.gulong(handle))) {
+			C.g_signal_handler_disconnect(gobject, C.gulong(handle))
+		}
+		return fs.Func.Call(args)
+	})
+
+	handle = v.connectClosure(false, detailedSignal, once.Interface())
+	return handle
+}
+
+// ConnectUnsafe is similar to Connect, except the callback is kept in a
+// slice-backed arena of v, indexed by the closure's data, instead of in the
+// closure registry of v. Adding to the arena only takes a lock of v's own and
+// doesn't allocate once indices are reused, which makes connecting cheaper for
+// handlers that are connected and disconnected at a very high rate; see
+// BenchmarkConnectDisconnect. In exchange, the callback can't be found by its
+// closure, so HandlerFind and the closure reaper don't see it.
+//
+// The callback is removed from the arena once its closure is finalized, which
+// GLib does when the handler is disconnected, so disconnecting using
+// HandlerDisconnect works as usual.
+func (v *Object) ConnectUnsafe(detailedSignal string, f interface{}) SignalHandle {
+	fs := closure.NewFuncStack(f, 1)
+	v.checkClosure(fs, detailedSignal)
+
+	cstr := C.CString(detailedSignal)
+	defer C.free(unsafe.Pointer(cstr))
+
+	index := v.box.Arena.Add(fs)
+
+	gclosure := C.g_closure_new_simple(C.sizeof_GClosure, C.gpointer(uintptr(index)))
+	C.g_closure_set_meta_marshal(gclosure, C.gpointer(v.GObject), (*[0]byte)(C.goMarshalUnsafe))
+	C.g_closure_add_finalize_notifier(gclosure, C.gpointer(v.GObject), (*[0]byte)(C.removeUnsafeClosure))
+
+	c := C.g_signal_connect_closure(C.gpointer(v.GObject), (*C.gchar)(cstr), gclosure, C.FALSE)
+	return SignalHandle(c)
+}
+
+// ConnectContext is similar to Connect, except the handler is disconnected
+// once ctx is done. The returned SignalHandle may still be used to disconnect
+// the handler earlier.
+//
+// A goroutine waits for ctx while only keeping a weak reference to v, so ctx
+// being done after v is finalized is fine, and lives until either happens.
+// Once ctx is done, the handler is disconnected from an idle source added like
+// IdleAdd, so v is only touched, and possibly finalized, on the main loop.
+func (v *Object) ConnectContext(ctx context.Context, detailedSignal string, f interface{}) SignalHandle {
+	handle := v.connectClosure(false, detailedSignal, f)
+	if handle == 0 || ctx.Done() == nil {
+		return handle
+	}
+
+	gobject := v.native()
+
+	weakRef := (*C.GWeakRef)(C.g_malloc0(C.sizeof_GWeakRef))
+	C.g_weak_ref_init(weakRef, C.gpointer(gobject))
+
+	// Only used to stop waiting for ctx once v is finalized.
+	finalized := make(chan struct{})
+	id := C.gpointer(callback.Assign(func() { close(finalized) }))
+	C.g_object_weak_ref(gobject, (*[0]byte)(C.goWeakNotify), id)
+
+	go func() {
+		select {
+		case <-finalized:
+			C.g_weak_ref_clear(weakRef)
+			C.g_free(C.gpointer(weakRef))
+			return
+		case <-ctx.Done():
+		}
+
+		IdleAdd(func() {
+			obj := (*C.GObject)(C.g_weak_ref_get(weakRef))
+			C.g_weak_ref_clear(weakRef)
+			C.g_free(C.gpointer(weakRef))
+
+			if obj == nil {
+				// Finalized in the meantime; the weak notify already ran.
+				return
+			}
+			defer C.g_object_unref(C.gpointer(obj))
+
+			C.g_object_weak_unref(obj, (*[0]byte)(C.goWeakNotify), id)
+			callback.Delete(uintptr(id))
+
+			if gobool(C.g_signal_handler_is_connected(C.gpointer(obj), C.gulong(handle))) {
+				C.g_signal_handler_disconnect(C.gpointer(obj), C.gulong(handle))
+			}
+		})
+	}()
+
+	return handle
+}
+
+// ConnectFuncStack is similar to Connect, except the signal is given by its ID
+// and detail, such as from SignalLookup, and the callback by an already built
+// FuncStack. This skips parsing the signal name and building the FuncStack
+// every time, so the same FuncStack can be connected to many objects at a
+// lower cost.
+//
+// Nothing is checked: signalID must be a signal of v's type, detail must be 0
+// unless the signal is detailed, and the callback of fs must be able to
+// receive the parameters of the signal, as it would with Connect. fs must not
+// be changed once connected, but it may be shared across objects.
+func (v *Object) ConnectFuncStack(signalID uint, detail Quark, fs *closure.FuncStack, after bool) SignalHandle {
+	gclosure := v.ClosureNew(fs)
+	c := C.g_signal_connect_closure_by_id(
+		C.gpointer(v.GObject), C.guint(signalID), C.GQuark(detail), gclosure, gbool(after))
+	if c != 0 {
+		connectedClosures.Store(unsafe.Pointer(gclosure), struct{}{})
+	}
+
+	return SignalHandle(c)
+}
+
+// ConnectData is similar to Connect, except the last parameter of f receives
+// data on every call, similarly to g_signal_connect()'s user_data. The rest of
+// the parameters of f are filled in the same way as Connect.
+//
+// data must be assignable or convertible to the type of the last parameter of
+// f, otherwise a runtime panic will occur. data is kept alive for as long as
+// the closure is.
+func (v *Object) ConnectData(detailedSignal string, f, data interface{}) SignalHandle {
+	return v.connectClosure(false, detailedSignal, bindData(f, data))
+}
+
+// bindData returns a function that calls f with data appended as its last
+// argument. The returned function has the same signature as f minus the last
+// parameter.
+func bindData(f, data interface{}) interface{} {
+	fs := closure.NewFuncStack(f, 2)
+	fsType := fs.Func.Type()
+
+	nIn := fsType.NumIn()
+	if nIn < 1 {
+		fs.Panicf("callback should have a parameter to receive the data")
+	}
+	if fsType.IsVariadic() {
+		fs.Panicf("variadic callback cannot receive the data")
+	}
+
+	dataType := fsType.In(nIn - 1)
+
+	var dataValue reflect.Value
+	if data == nil {
+		dataValue = reflect.Zero(dataType)
+	} else {
+		dataValue = reflect.ValueOf(data)
+		switch {
+		case dataValue.Type().AssignableTo(dataType):
+			// ok
+		case dataValue.Type().ConvertibleTo(dataType):
+			dataValue = dataValue.Convert(dataType)
+		default:
+			fs.Panicf("data of type %s not convertible to %s", dataValue.Type(), dataType)
+		}
+	}
+
+	in := make([]reflect.Type, nIn-1)
+	for i := range in {
+		in[i] = fsType.In(i)
+	}
+
+	out := make([]reflect.Type, fsType.NumOut())
+	for i := range out {
+		out[i] = fsType.Out(i)
+	}
+
+	boundType := reflect.FuncOf(in, out, false)
+	bound := reflect.MakeFunc(boundType, func(args []reflect.Value) []reflect.Value {
+		return fs.Func.Call(append(args, dataValue))
+	})
+
+	return bound.Interface()
+}
+
+// HandlerFind is a wrapper around g_signal_handler_find(). It finds the handler
+// of the given signal whose callback is f, as connected by Connect and its
+// variants that keep f as-is, and returns its handle. If the detailed signal
+// has no detail, then handlers of any detail are matched. False is returned if
+// no such handler is connected.
+//
+// Funcs are compared by identity, so a closure only matches the handlers that
+// it was connected as, not those of other closures created from the same
+// literal. If f was connected more than once, the handle of the earliest
+// connection is returned. Handlers connected by ConnectUnsafe, ConnectOnce or
+// ConnectData are not found.
+func (v *Object) HandlerFind(detailedSignal string, f interface{}) (SignalHandle, bool) {
+	if reflect.ValueOf(f).Kind() != reflect.Func {
+		return 0, false
+	}
+
+	cstr := C.CString(detailedSignal)
+	defer C.free(unsafe.Pointer(cstr))
+
+	var signalID C.guint
+	var detail C.GQuark
+
+	if !gobool(C.g_signal_parse_name((*C.gchar)(cstr), C._g_type_from_instance(C.gpointer(v.native())), &signalID, &detail, C.FALSE)) {
+		return 0, false
+	}
+
+	mask := C.GSignalMatchType(C.G_SIGNAL_MATCH_ID | C.G_SIGNAL_MATCH_CLOSURE)
+	if detail != 0 {
+		mask |= C.G_SIGNAL_MATCH_DETAIL
+	}
+
+	fn := funcValue(f)
+
+	// The registry is unordered, so go through all of its closures and keep
+	// the earliest handler, whose handle is the lowest.
+	var handle SignalHandle
+	v.box.Closures.Range(func(gclosure unsafe.Pointer, fs *closure.FuncStack) bool {
+		if funcValue(fs.Func.Interface()) != fn {
+			return true
+		}
+
+		handler := SignalHandle(C.g_signal_handler_find(
+			C.gpointer(v.GObject), mask, signalID, detail, (*C.GClosure)(gclosure), nil, nil))
+		if handler != 0 && (handle == 0 || handler < handle) {
+			handle = handler
+		}
+
+		return true
+	})
+
+	return handle, handle != 0
+}
+
+// funcValue returns the pointer to the func value that f holds, which tells
+// closures apart: closures created from the same literal share their code, but
+// each has a func value of its own holding its captured variables.
+func funcValue(f interface{}) unsafe.Pointer {
+	// A func is stored directly in the data word of the interface.
+	return (*[2]unsafe.Pointer)(unsafe.Pointer(&f))[1]
+}
+
 // ClosureCheckReceiver, if true, will make GLib check for every single
 // closure's first argument to ensure that it is correct, otherwise it will
 // panic with a message warning about the possible circular references. The
@@ -85,10 +500,64 @@ func (v *Object) ConnectAfter(detailedSignal string, f interface{}) SignalHandle
 // debugging purposes.
 const ClosureCheckReceiver = false
 
+// StrictConnect, if true, will make Connect resolve the parameter types of the
+// signal and check that every parameter of the callback can receive the Go
+// value of the corresponding signal parameter, panicking with a message naming
+// the mismatched parameter if not. This catches mismatches when connecting
+// rather than when the signal is first emitted, at the cost of slower
+// connecting. Parameters whose Go values depend on the emitted values, such as
+// objects and variants, are only loosely checked.
+//
+// StrictConnect is meant to be set once on initialization, usually for
+// debugging purposes.
+var StrictConnect = false
+
+// WarnOnExtraArgs, if true, will make callbacks with more parameters than their
+// signal provides receive zero values for the extra parameters, with a warning
+// logged using the standard logger every time, instead of panicking when the
+// signal is emitted. This eases migrating handlers between signal versions.
+//
+// WarnOnExtraArgs is meant to be set once on initialization.
+var WarnOnExtraArgs = false
+
+// WarnNoReceiver, if true, will make Connect log a warning using the standard
+// logger when the callback takes no parameters at all. Such callbacks usually
+// capture the object they're connected to, creating a circular reference that
+// keeps it alive forever. Unlike ClosureCheckReceiver, the receiver's type is
+// not checked, so this is cheap enough to leave on.
+//
+// WarnNoReceiver is meant to be set once on initialization.
+var WarnNoReceiver = false
+
 func (v *Object) connectClosure(after bool, detailedSignal string, f interface{}) SignalHandle {
 	fs := closure.NewFuncStack(f, 2)
+	v.checkClosure(fs, detailedSignal)
 
-	if ClosureCheckReceiver {
+	cstr := C.CString(detailedSignal)
+	defer C.free(unsafe.Pointer(cstr))
+
+	gclosure := v.ClosureNew(fs)
+	c := C.g_signal_connect_closure(C.gpointer(v.GObject), (*C.gchar)(cstr), gclosure, gbool(after))
+	if c != 0 {
+		connectedClosures.Store(unsafe.Pointer(gclosure), struct{}{})
+	}
+
+	return SignalHandle(c)
+}
+
+// checkClosure runs the checks enabled by StrictConnect, WarnNoReceiver and
+// ClosureCheckReceiver on the callback in fs.
+func (v *Object) checkClosure(fs *closure.FuncStack, detailedSignal string) {
+	if StrictConnect {
+		v.checkSignalParams(fs, detailedSignal)
+	}
+
+	if WarnNoReceiver && fs.Func.Type().NumIn() == 0 {
+		fs.Warnf("callback for signal %q has no object receiver; "+
+			"capturing the object instead may cause circular references", detailedSignal)
+	}
+
+	if ClosureCheckReceiver && fs.Func.Type() != rawHandlerType {
 		// This is a bit slow, but we could be careful.
 		objValue, err := v.goValue()
 		if err == nil {
@@ -106,14 +575,107 @@ func (v *Object) connectClosure(after bool, detailedSignal string, f interface{}
 		// rarely happens, but it might, and we want to at least allow working
 		// around it.
 	}
+}
 
-	cstr := C.CString(detailedSignal)
+// checkSignalParams panics if the parameters of the callback in fs cannot
+// receive the parameters of the given signal. The first parameter, which
+// receives the instance, is not checked; see ClosureCheckReceiver for that.
+func (v *Object) checkSignalParams(fs *closure.FuncStack, detailedSignal string) {
+	cstr := (*C.gchar)(C.CString(detailedSignal))
 	defer C.free(unsafe.Pointer(cstr))
 
-	gclosure := v.ClosureNew(fs)
-	c := C.g_signal_connect_closure(C.gpointer(v.GObject), (*C.gchar)(cstr), gclosure, gbool(after))
+	var signalID C.guint
+	var detail C.GQuark
 
-	return SignalHandle(c)
+	if !gobool(C.g_signal_parse_name(cstr, C._g_type_from_instance(C.gpointer(v.native())), &signalID, &detail, C.FALSE)) {
+		fs.Panicf("unknown signal %q for type %s", detailedSignal, v.TypeFromInstance().Name())
+	}
+
+	var query C.GSignalQuery
+	C.g_signal_query(signalID, &query)
+
+	fsType := fs.Func.Type()
+	if nParams := int(query.n_params) + 1; fsType.NumIn() > nParams {
+		fs.Panicf("too many closure args for signal %q: have %d, max %d", detailedSignal, fsType.NumIn(), nParams)
+	}
+
+	for i := 1; i < fsType.NumIn(); i++ {
+		paramType := Type(C._g_signal_query_param_type(&query, C.guint(i-1)))
+		if !signalParamAccepts(fsType.In(i), paramType) {
+			fs.Panicf("closure arg %d of type %s cannot receive %s of signal %q",
+				i, fsType.In(i), paramType.Name(), detailedSignal)
+		}
+	}
+}
+
+// signalParamAccepts returns true if a callback parameter of type in can
+// receive the values of the given signal parameter type, the same way
+// goMarshal converts them.
+func signalParamAccepts(in reflect.Type, paramType Type) bool {
+	if in.Kind() == reflect.Interface {
+		// Any value may be packed into an empty interface, and whether others
+		// are implemented depends on the actual value.
+		return true
+	}
+
+	var goType reflect.Type
+
+	switch Type(C._g_value_fundamental(C.GType(paramType))) {
+	case TYPE_BOOLEAN:
+		goType = reflect.TypeOf(false)
+	case TYPE_CHAR:
+		goType = reflect.TypeOf(int8(0))
+	case TYPE_UCHAR:
+		goType = reflect.TypeOf(uint8(0))
+	case TYPE_INT, TYPE_LONG:
+		goType = reflect.TypeOf(int(0))
+	case TYPE_UINT, TYPE_ULONG, TYPE_FLAGS:
+		goType = reflect.TypeOf(uint(0))
+	case TYPE_INT64:
+		goType = reflect.TypeOf(int64(0))
+	case TYPE_UINT64:
+		goType = reflect.TypeOf(uint64(0))
+	case TYPE_FLOAT:
+		goType = reflect.TypeOf(float32(0))
+	case TYPE_DOUBLE:
+		goType = reflect.TypeOf(float64(0))
+	case TYPE_STRING:
+		goType = reflect.TypeOf("")
+	case TYPE_POINTER:
+		goType = reflect.TypeOf(unsafe.Pointer(nil))
+	case TYPE_ENUM:
+		// Enums may also be received as their nicks.
+		if in.Kind() == reflect.String {
+			return true
+		}
+		goType = reflect.TypeOf(int(0))
+	case TYPE_BOXED:
+		if in == objectSliceType && paramType == Type(C.G_TYPE_PTR_ARRAY) {
+			return true
+		}
+		if in == valueType && paramType == Type(C.G_TYPE_VALUE) {
+			return true
+		}
+		goType = reflect.TypeOf(uintptr(0))
+	case TYPE_PARAM:
+		return in == paramSpecType
+	case TYPE_OBJECT, TYPE_INTERFACE:
+		// Objects are converted to the Go type registered for their actual
+		// type, which is only known once emitted.
+		return in.Kind() == reflect.Ptr
+	default:
+		// Variants are converted depending on their actual type, and other
+		// types depend on the registered marshalers.
+		return true
+	}
+
+	// Go allows converting integers to strings, which goMarshal would happily
+	// do, but that's never what's wanted.
+	if (goType.Kind() == reflect.String) != (in.Kind() == reflect.String) {
+		return false
+	}
+
+	return goType.ConvertibleTo(in)
 }
 
 // ClosureNew creates a new GClosure that's bound to the current object and adds
@@ -134,7 +696,149 @@ func (v *Object) ClosureNew(f interface{}) *C.GClosure {
 	return gclosure
 }
 
+// namedClosures holds the callbacks registered using RegisterClosure.
+var namedClosures = struct {
+	sync.RWMutex
+	funcs map[string]*closure.FuncStack
+}{
+	funcs: make(map[string]*closure.FuncStack),
+}
+
+// RegisterClosure registers f under the given name, so that it can be referred
+// to by name, similarly to how GtkBuilder resolves callback names. f must be a
+// function; it replaces any function previously registered under the name.
+// See BindPropertyNamed.
+func RegisterClosure(name string, f interface{}) {
+	fs := closure.NewFuncStack(f, 2)
+
+	namedClosures.Lock()
+	namedClosures.funcs[name] = fs
+	namedClosures.Unlock()
+}
+
+// namedClosure returns the function registered under the given name. Nil is
+// returned if name is empty, and an error if no function is registered under
+// it.
+func namedClosure(name string) (*closure.FuncStack, error) {
+	if name == "" {
+		return nil, nil
+	}
+
+	namedClosures.RLock()
+	fs, ok := namedClosures.funcs[name]
+	namedClosures.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("no closure registered as %q", name)
+	}
+
+	return fs, nil
+}
+
 //export removeClosure
 func removeClosure(obj *C.GObject, gclosure *C.GClosure) {
+	connectedClosures.Delete(unsafe.Pointer(gclosure))
 	intern.RemoveClosure(unsafe.Pointer(obj), unsafe.Pointer(gclosure))
 }
+
+//export removeUnsafeClosure
+func removeUnsafeClosure(obj *C.GObject, gclosure *C.GClosure) {
+	intern.RemoveArenaClosure(unsafe.Pointer(obj), int(uintptr(gclosure.data)))
+}
+
+// connectedClosures contains the closures that have been connected as signal
+// handlers by Connect and haven't been finalized yet. Only these closures are
+// considered by the closure reaper, since closures made by ClosureNew may be
+// used for anything else.
+var connectedClosures sync.Map // unsafe.Pointer(*C.GClosure) -> struct{}
+
+var closureReaper struct {
+	mu   sync.Mutex
+	stop chan struct{}
+}
+
+// StartClosureReaper starts periodically removing the callbacks of signal
+// handlers that have been disconnected, but whose closures haven't been
+// finalized yet because something still references them. Normally, callbacks
+// are removed once their closures are finalized, so this is only useful for
+// applications that connect and disconnect many handlers. If the reaper is
+// already running, then it is restarted with the given interval.
+func StartClosureReaper(interval time.Duration) {
+	closureReaper.mu.Lock()
+	defer closureReaper.mu.Unlock()
+
+	if closureReaper.stop != nil {
+		close(closureReaper.stop)
+	}
+
+	stop := make(chan struct{})
+	closureReaper.stop = stop
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				reapClosures()
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
+
+// StopClosureReaper stops the reaper started by StartClosureReaper. It does
+// nothing if the reaper isn't running.
+func StopClosureReaper() {
+	closureReaper.mu.Lock()
+	defer closureReaper.mu.Unlock()
+
+	if closureReaper.stop != nil {
+		close(closureReaper.stop)
+		closureReaper.stop = nil
+	}
+}
+
+// reapClosures removes the callbacks of all connected closures that are no
+// longer connected to their objects and returns the number of removed
+// callbacks.
+func reapClosures() int {
+	return intern.ReapClosures(func(gobject unsafe.Pointer) func([]unsafe.Pointer) []unsafe.Pointer {
+		// The object is only known to be alive until the closures are checked
+		// here, and taking a reference could call the toggle notify, which
+		// calls back into intern, so only take a weak reference for now.
+		weakRef := (*C.GWeakRef)(C.g_malloc0(C.sizeof_GWeakRef))
+		C.g_weak_ref_init(weakRef, C.gpointer(gobject))
+
+		return func(gclosures []unsafe.Pointer) []unsafe.Pointer {
+			obj := C.g_weak_ref_get(weakRef)
+			C.g_weak_ref_clear(weakRef)
+			C.g_free(C.gpointer(weakRef))
+
+			if obj == nil {
+				return nil
+			}
+			defer C.g_object_unref(obj)
+
+			var stale []unsafe.Pointer
+			for _, gclosure := range gclosures {
+				if _, ok := connectedClosures.Load(gclosure); !ok {
+					continue
+				}
+
+				handler := C.g_signal_handler_find(
+					obj, C.G_SIGNAL_MATCH_CLOSURE, 0, 0, (*C.GClosure)(gclosure), nil, nil)
+				if handler != 0 {
+					continue
+				}
+
+				connectedClosures.Delete(gclosure)
+				stale = append(stale, gclosure)
+			}
+
+			return stale
+		}
+	})
+}
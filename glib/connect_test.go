@@ -0,0 +1,1098 @@
+// Same copyright and license as the rest of the files in this project
+
+package glib_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/diamondburned/go-glib/core/closure"
+	"github.com/diamondburned/go-glib/glib"
+	"github.com/diamondburned/go-glib/glib/internal/testobject"
+)
+
+func newTestObject() *glib.Object {
+	return glib.ObjectNew(glib.Type(testobject.Type()))
+}
+
+// Signals can only be registered once per process, like types.
+var (
+	signalerType = glib.RegisterSubclass(glib.TYPE_OBJECT, "GoGlibTestSignaler", nil, nil)
+
+	pokeSignal = mustSignalNewv(signalerType, "poke", glib.SIGNAL_RUN_FIRST, glib.TYPE_NONE, nil)
+	pingSignal = mustSignalNewv(signalerType, "ping", glib.SIGNAL_RUN_LAST,
+		glib.TYPE_NONE, []glib.Type{glib.TYPE_INT, glib.TYPE_STRING})
+	// gsize and gssize are registered as unsigned and signed longs.
+	sizeSignal = mustSignalNewv(signalerType, "size", glib.SIGNAL_RUN_LAST,
+		glib.TYPE_NONE, []glib.Type{glib.TYPE_ULONG, glib.TYPE_LONG, glib.TYPE_UINT64})
+)
+
+func mustSignalNewv(objType glib.Type, name string, flags glib.SignalFlags, returnType glib.Type, paramTypes []glib.Type) uint {
+	id, err := glib.SignalNewv(objType, name, flags, returnType, paramTypes)
+	if err != nil {
+		panic(err)
+	}
+	return id
+}
+
+func TestConnectData(t *testing.T) {
+	type userData struct {
+		Name  string
+		Count int
+	}
+
+	obj := newTestObject()
+	data := userData{Name: "user data", Count: 3}
+
+	var called bool
+
+	obj.ConnectData("int-string", func(obj *glib.Object, i int, s string, d userData) {
+		called = true
+
+		if obj == nil {
+			t.Error("Expected non-nil receiver")
+		}
+		if i != 42 {
+			t.Error("Expected", 42, "got", i)
+		}
+		if s != "hello" {
+			t.Error("Expected", "hello", "got", s)
+		}
+		if d != data {
+			t.Error("Expected", data, "got", d)
+		}
+	}, data)
+
+	obj.Emit("int-string", 42, "hello")
+
+	if !called {
+		t.Error("Expected callback to be called")
+	}
+}
+
+func TestConnectDataInvalid(t *testing.T) {
+	obj := newTestObject()
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic, did not get one")
+		}
+	}()
+
+	obj.ConnectData("int-string", func(obj *glib.Object, d int) {}, "not an int")
+}
+
+func TestMarshalReentrant(t *testing.T) {
+	obj := newTestObject()
+
+	var seen []int
+
+	obj.Connect("int", func(obj *glib.Object, i int) {
+		seen = append(seen, i)
+
+		if i < 3 {
+			testobject.EmitInt(obj.Native(), i+1)
+		}
+
+		// The nested emissions must not have touched our arguments.
+		seen = append(seen, i)
+	})
+
+	testobject.EmitInt(obj.Native(), 1)
+
+	expected := []int{1, 2, 3, 3, 2, 1}
+	if len(seen) != len(expected) {
+		t.Fatal("Expected", expected, "got", seen)
+	}
+	for i := range expected {
+		if seen[i] != expected[i] {
+			t.Fatal("Expected", expected, "got", seen)
+		}
+	}
+}
+
+func TestMarshalSequential(t *testing.T) {
+	obj := newTestObject()
+
+	type args struct {
+		i int
+		s string
+		b bool
+	}
+
+	var got []args
+
+	obj.Connect("int-string-boolean", func(obj *glib.Object, i int, s string, b bool) {
+		got = append(got, args{i, s, b})
+	})
+	obj.Connect("int-string", func(obj *glib.Object, i int, s string) {
+		got = append(got, args{i: i, s: s})
+	})
+
+	testobject.EmitIntStringBoolean(obj.Native(), 1, "first", true)
+	testobject.EmitIntString(obj.Native(), 2, "second")
+	testobject.EmitIntStringBoolean(obj.Native(), 3, "third", false)
+
+	expected := []args{
+		{1, "first", true},
+		{2, "second", false},
+		{3, "third", false},
+	}
+	if len(got) != len(expected) {
+		t.Fatal("Expected", expected, "got", got)
+	}
+	for i := range expected {
+		if got[i] != expected[i] {
+			t.Error("Expected", expected[i], "got", got[i])
+		}
+	}
+}
+
+func BenchmarkMarshal(b *testing.B) {
+	b.Run("0 args", func(b *testing.B) {
+		obj := newTestObject()
+		obj.Connect("no-args", func(obj *glib.Object) {})
+		ptr := obj.Native()
+
+		b.ReportAllocs()
+		b.ResetTimer()
+
+		for i := 0; i < b.N; i++ {
+			testobject.EmitNoArgs(ptr)
+		}
+	})
+
+	b.Run("1 arg", func(b *testing.B) {
+		obj := newTestObject()
+		obj.Connect("int", func(obj *glib.Object, i int) {})
+		ptr := obj.Native()
+
+		b.ReportAllocs()
+		b.ResetTimer()
+
+		for i := 0; i < b.N; i++ {
+			testobject.EmitInt(ptr, i)
+		}
+	})
+
+	b.Run("2 args", func(b *testing.B) {
+		obj := newTestObject()
+		obj.Connect("int-string", func(obj *glib.Object, i int, s string) {})
+		ptr := obj.Native()
+
+		b.ReportAllocs()
+		b.ResetTimer()
+
+		for i := 0; i < b.N; i++ {
+			testobject.EmitIntString(ptr, i, "benchmark")
+		}
+	})
+
+	b.Run("3 args", func(b *testing.B) {
+		obj := newTestObject()
+		obj.Connect("int-string-boolean", func(obj *glib.Object, i int, s string, v bool) {})
+		ptr := obj.Native()
+
+		b.ReportAllocs()
+		b.ResetTimer()
+
+		for i := 0; i < b.N; i++ {
+			testobject.EmitIntStringBoolean(ptr, i, "benchmark", true)
+		}
+	})
+}
+
+// BenchmarkConnectDisconnect is best run with -benchtime=100000x to compare
+// 100k connect and disconnect cycles.
+func BenchmarkConnectDisconnect(b *testing.B) {
+	b.Run("Connect", func(b *testing.B) {
+		obj := newTestObject()
+
+		b.ReportAllocs()
+		b.ResetTimer()
+
+		for i := 0; i < b.N; i++ {
+			obj.HandlerDisconnect(obj.Connect("int", func(obj *glib.Object, i int) {}))
+		}
+	})
+
+	b.Run("ConnectFuncStack", func(b *testing.B) {
+		obj := newTestObject()
+		id := glib.SignalLookup("int", obj.TypeFromInstance())
+		fs := closure.NewFuncStack(func(obj *glib.Object, i int) {}, 0)
+
+		b.ReportAllocs()
+		b.ResetTimer()
+
+		for i := 0; i < b.N; i++ {
+			obj.HandlerDisconnect(obj.ConnectFuncStack(id, 0, fs, false))
+		}
+	})
+
+	b.Run("ConnectUnsafe", func(b *testing.B) {
+		obj := newTestObject()
+
+		b.ReportAllocs()
+		b.ResetTimer()
+
+		for i := 0; i < b.N; i++ {
+			obj.HandlerDisconnect(obj.ConnectUnsafe("int", func(obj *glib.Object, i int) {}))
+		}
+	})
+}
+
+// BenchmarkConnectDisconnectParallel connects and disconnects handlers from
+// several goroutines, each with an object of its own, which ConnectUnsafe
+// doesn't synchronize between.
+func BenchmarkConnectDisconnectParallel(b *testing.B) {
+	b.Run("Connect", func(b *testing.B) {
+		b.ReportAllocs()
+		b.RunParallel(func(pb *testing.PB) {
+			obj := newTestObject()
+			for pb.Next() {
+				obj.HandlerDisconnect(obj.Connect("int", func(obj *glib.Object, i int) {}))
+			}
+		})
+	})
+
+	b.Run("ConnectUnsafe", func(b *testing.B) {
+		b.ReportAllocs()
+		b.RunParallel(func(pb *testing.PB) {
+			obj := newTestObject()
+			for pb.Next() {
+				obj.HandlerDisconnect(obj.ConnectUnsafe("int", func(obj *glib.Object, i int) {}))
+			}
+		})
+	})
+}
+
+func TestListSignals(t *testing.T) {
+	obj := newTestObject()
+	signals := obj.ListSignals()
+
+	for _, name := range []string{"no-args", "int", "int-string", "notify"} {
+		var found bool
+		for _, signal := range signals {
+			if signal == name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			t.Error("Expected signal", name, "in", signals)
+		}
+	}
+}
+
+func TestMarshalEnumNick(t *testing.T) {
+	obj := newTestObject()
+
+	var nick string
+	var value int
+
+	obj.Connect("enum", func(obj *glib.Object, mode string) { nick = mode })
+	obj.Connect("enum", func(obj *glib.Object, mode int) { value = mode })
+
+	testobject.EmitEnum(obj.Native(), testobject.ModeFast)
+
+	if nick != "fast" {
+		t.Error("Expected", "fast", "got", nick)
+	}
+	if value != testobject.ModeFast {
+		t.Error("Expected", testobject.ModeFast, "got", value)
+	}
+}
+
+func TestMarshalObjectSlice(t *testing.T) {
+	obj := newTestObject()
+	children := []*glib.Object{newTestObject(), newTestObject(), newTestObject()}
+
+	var got []*glib.Object
+
+	obj.Connect("objects", func(obj *glib.Object, objs []*glib.Object) {
+		got = objs
+	})
+
+	ptrs := make([]uintptr, len(children))
+	for i, child := range children {
+		ptrs[i] = child.Native()
+	}
+
+	testobject.EmitObjects(obj.Native(), ptrs)
+
+	if len(got) != len(children) {
+		t.Fatal("Expected", len(children), "objects, got", len(got))
+	}
+	for i := range children {
+		if got[i].Native() != children[i].Native() {
+			t.Error("Expected object", i, "to be", children[i].Native(), "got", got[i].Native())
+		}
+	}
+
+	testobject.EmitObjects(obj.Native(), nil)
+
+	if len(got) != 0 {
+		t.Error("Expected no objects, got", len(got))
+	}
+}
+
+func TestStrictConnect(t *testing.T) {
+	glib.StrictConnect = true
+	defer func() { glib.StrictConnect = false }()
+
+	obj := newTestObject()
+
+	// Matching callbacks must connect fine.
+	obj.Connect("int-string", func(obj *glib.Object, i int, s string) {})
+	obj.Connect("enum", func(obj *glib.Object, mode string) {})
+	obj.Connect("objects", func(obj *glib.Object, objs []*glib.Object) {})
+
+	testCases := []struct {
+		desc   string
+		signal string
+		f      interface{}
+		arg    string
+	}{
+		{
+			desc:   "string for int",
+			signal: "int-string",
+			f:      func(obj *glib.Object, i string, s string) {},
+			arg:    "closure arg 1",
+		},
+		{
+			desc:   "bool for string",
+			signal: "int-string",
+			f:      func(obj *glib.Object, i int, s bool) {},
+			arg:    "closure arg 2",
+		},
+		{
+			desc:   "too many args",
+			signal: "int",
+			f:      func(obj *glib.Object, i, j int) {},
+			arg:    "too many closure args",
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			defer func() {
+				r := recover()
+				if r == nil {
+					t.Fatal("expected panic, did not get one")
+				}
+				if msg := fmt.Sprint(r); !strings.Contains(msg, tC.arg) {
+					t.Error("Expected panic mentioning", tC.arg, "got", msg)
+				}
+			}()
+
+			obj.Connect(tC.signal, tC.f)
+		})
+	}
+}
+
+func TestMarshalNotifyParamSpec(t *testing.T) {
+	obj := newTestObject()
+
+	var names []string
+	obj.Connect("notify", func(obj *glib.Object, pspec *glib.ParamSpec) {
+		names = append(names, pspec.Name())
+
+		if pspec.ValueType() != glib.TYPE_INT && pspec.ValueType() != glib.TYPE_STRING {
+			t.Error("Unexpected property type", pspec.ValueType().Name())
+		}
+	})
+
+	obj.SetProperty("int", 1)
+	obj.SetProperty("string", "changed")
+
+	expected := []string{"int", "string"}
+	if len(names) != len(expected) {
+		t.Fatal("Expected", expected, "got", names)
+	}
+	for i := range expected {
+		if names[i] != expected[i] {
+			t.Error("Expected", expected[i], "got", names[i])
+		}
+	}
+}
+
+func TestSignalEmissionStats(t *testing.T) {
+	obj := newTestObject()
+	obj.Connect("int", func(obj *glib.Object, i int) {})
+	obj.Connect("no-args", func(obj *glib.Object) {})
+
+	typeName := obj.TypeFromInstance().Name()
+	before := glib.SignalEmissionStats()
+
+	testobject.EmitInt(obj.Native(), 1)
+
+	glib.EnableEmissionStats(true)
+	testobject.EmitInt(obj.Native(), 2)
+	testobject.EmitInt(obj.Native(), 3)
+	testobject.EmitNoArgs(obj.Native())
+	glib.EnableEmissionStats(false)
+
+	testobject.EmitNoArgs(obj.Native())
+
+	after := glib.SignalEmissionStats()
+
+	expected := map[string]uint64{
+		typeName + "::int":     2,
+		typeName + "::no-args": 1,
+	}
+	for key, count := range expected {
+		if actual := after[key] - before[key]; actual != count {
+			t.Error("Expected", count, "emissions of", key, "got", actual)
+		}
+	}
+}
+
+func TestConnectNotify(t *testing.T) {
+	obj := newTestObject()
+
+	var names []string
+	handle := obj.ConnectNotify(func(obj *glib.Object, pspec *glib.ParamSpec) {
+		names = append(names, pspec.Name())
+	})
+
+	obj.SetProperty("int", 1)
+	obj.SetProperty("boolean", true)
+	obj.SetProperty("double", 2.5)
+
+	obj.HandlerDisconnect(handle)
+	obj.SetProperty("string", "ignored")
+
+	expected := []string{"int", "boolean", "double"}
+	if len(names) != len(expected) {
+		t.Fatal("Expected", expected, "got", names)
+	}
+	for i := range expected {
+		if names[i] != expected[i] {
+			t.Error("Expected", expected[i], "got", names[i])
+		}
+	}
+}
+
+func TestConnectReturnValue(t *testing.T) {
+	t.Run("boolean", func(t *testing.T) {
+		obj := newTestObject()
+
+		if testobject.EmitHandled(obj.Native()) {
+			t.Error("Expected false without handlers")
+		}
+
+		var calls int
+		obj.Connect("handled", func(obj *glib.Object) bool {
+			calls++
+			return true
+		})
+		// Never called, since the first handler returning true stops the
+		// emission.
+		obj.Connect("handled", func(obj *glib.Object) bool {
+			calls++
+			return false
+		})
+
+		if !testobject.EmitHandled(obj.Native()) {
+			t.Error("Expected the handler's true to be returned")
+		}
+		if calls != 1 {
+			t.Error("Expected", 1, "call, got", calls)
+		}
+	})
+
+	t.Run("converted", func(t *testing.T) {
+		obj := newTestObject()
+
+		// The signal returns a guint, so the int must be converted.
+		obj.Connect("count", func(obj *glib.Object) int { return 42 })
+
+		if count := testobject.EmitCount(obj.Native()); count != 42 {
+			t.Error("Expected", 42, "got", count)
+		}
+	})
+}
+
+func TestDetailedSignal(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		name     string
+		detail   string
+		expected string
+	}{
+		{desc: "no detail", name: "notify", expected: "notify"},
+		{desc: "detail", name: "notify", detail: "int", expected: "notify::int"},
+		{desc: "dashes", name: "int-string", detail: "some_detail", expected: "int-string::some_detail"},
+		{desc: "dotted detail", name: "activate", detail: "app.quit", expected: "activate::app.quit"},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			actual := glib.DetailedSignal(tC.name, tC.detail)
+			if actual != tC.expected {
+				t.Error("Expected", tC.expected, "got", actual)
+			}
+		})
+	}
+
+	invalid := []struct {
+		desc   string
+		name   string
+		detail string
+	}{
+		{desc: "empty name", name: ""},
+		{desc: "space in name", name: "no args"},
+		{desc: "leading digit", name: "1st"},
+		{desc: "detail in name", name: "notify::int"},
+		{desc: "space in detail", name: "notify", detail: "some int"},
+		{desc: "colon in detail", name: "notify", detail: ":int"},
+	}
+
+	for _, tC := range invalid {
+		t.Run(tC.desc, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("expected panic, did not get one")
+				}
+			}()
+
+			glib.DetailedSignal(tC.name, tC.detail)
+		})
+	}
+}
+
+func TestConnectRaw(t *testing.T) {
+	obj := newTestObject()
+
+	var types []glib.Type
+	var goValues []interface{}
+
+	obj.ConnectRaw("int-string-boolean", func(values []*glib.Value) *glib.Value {
+		for _, value := range values {
+			actual, _, _ := value.Type()
+			types = append(types, actual)
+
+			v, _ := value.GoValue()
+			goValues = append(goValues, v)
+		}
+		return nil
+	})
+
+	testobject.EmitIntStringBoolean(obj.Native(), 3, "three", true)
+
+	expectedTypes := []glib.Type{
+		glib.Type(testobject.Type()), glib.TYPE_INT, glib.TYPE_STRING, glib.TYPE_BOOLEAN,
+	}
+	if len(types) != len(expectedTypes) {
+		t.Fatal("Expected", len(expectedTypes), "values, got", len(types))
+	}
+	for i := range expectedTypes {
+		if types[i] != expectedTypes[i] {
+			t.Error("Expected type", expectedTypes[i], "at", i, "got", types[i])
+		}
+	}
+	for i, expected := range []interface{}{3, "three", true} {
+		if goValues[i+1] != expected {
+			t.Error("Expected", expected, "at", i+1, "got", goValues[i+1])
+		}
+	}
+
+	obj.ConnectRaw("count", func(values []*glib.Value) *glib.Value {
+		v, _ := glib.GValue(9)
+		return v
+	})
+
+	if count := testobject.EmitCount(obj.Native()); count != 9 {
+		t.Error("Expected", 9, "got", count)
+	}
+}
+
+func TestWarnOnExtraArgs(t *testing.T) {
+	glib.WarnOnExtraArgs = true
+	defer func() { glib.WarnOnExtraArgs = false }()
+
+	obj := newTestObject()
+
+	var got []interface{}
+	obj.Connect("int", func(obj *glib.Object, i int, s string, b bool) {
+		got = append(got, i, s, b)
+	})
+
+	testobject.EmitInt(obj.Native(), 4)
+
+	expected := []interface{}{4, "", false}
+	if len(got) != len(expected) {
+		t.Fatal("Expected", expected, "got", got)
+	}
+	for i := range expected {
+		if got[i] != expected[i] {
+			t.Error("Expected", expected, "got", got)
+			break
+		}
+	}
+}
+
+func TestWarnNoReceiver(t *testing.T) {
+	glib.WarnNoReceiver = true
+	defer func() { glib.WarnNoReceiver = false }()
+
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	obj := newTestObject()
+
+	obj.Connect("int", func(obj *glib.Object, i int) {})
+	if buf.Len() != 0 {
+		t.Error("Expected no warning with a receiver, got", buf.String())
+	}
+
+	obj.Connect("int", func() {})
+	if !strings.Contains(buf.String(), "no object receiver") {
+		t.Error("Expected a warning without a receiver, got", buf.String())
+	}
+}
+
+func TestConnectUnsafe(t *testing.T) {
+	obj := newTestObject()
+
+	var got []int
+	h := obj.ConnectUnsafe("int", func(obj *glib.Object, i int) {
+		got = append(got, i)
+	})
+
+	testobject.EmitInt(obj.Native(), 1)
+	testobject.EmitInt(obj.Native(), 2)
+
+	obj.HandlerDisconnect(h)
+	testobject.EmitInt(obj.Native(), 3)
+
+	if len(got) != 2 || got[0] != 1 || got[1] != 2 {
+		t.Error("Expected", []int{1, 2}, "got", got)
+	}
+
+	// The index of the disconnected handler is reused by the next one.
+	h = obj.ConnectUnsafe("int", func(obj *glib.Object, i int) {
+		got = append(got, -i)
+	})
+	defer obj.HandlerDisconnect(h)
+
+	testobject.EmitInt(obj.Native(), 4)
+	if len(got) != 3 || got[2] != -4 {
+		t.Error("Expected", []int{1, 2, -4}, "got", got)
+	}
+}
+
+func TestConnectContext(t *testing.T) {
+	// iterate iterates the default main context for a while, which is where
+	// the handler is disconnected once the context is done.
+	iterate := func(done func() bool) bool {
+		ctx := glib.MainContextDefault()
+		deadline := time.Now().Add(5 * time.Second)
+		for !done() {
+			if time.Now().After(deadline) {
+				return false
+			}
+			ctx.Iteration(false)
+			time.Sleep(time.Millisecond)
+		}
+		return true
+	}
+
+	// waitDisconnected waits for the handler to be disconnected.
+	waitDisconnected := func(t *testing.T, obj *glib.Object, h glib.SignalHandle) {
+		t.Helper()
+
+		if !iterate(func() bool { return !obj.HandlerIsConnected(h) }) {
+			t.Fatal("Expected the handler to be disconnected")
+		}
+	}
+
+	t.Run("cancel", func(t *testing.T) {
+		obj := newTestObject()
+		ctx, cancel := context.WithCancel(context.Background())
+
+		var got []int
+		h := obj.ConnectContext(ctx, "int", func(obj *glib.Object, i int) {
+			got = append(got, i)
+		})
+
+		testobject.EmitInt(obj.Native(), 1)
+
+		cancel()
+
+		// Nothing happens until the main context is iterated.
+		time.Sleep(10 * time.Millisecond)
+		if !obj.HandlerIsConnected(h) {
+			t.Error("Expected the handler to be disconnected from the main context")
+		}
+
+		waitDisconnected(t, obj, h)
+
+		testobject.EmitInt(obj.Native(), 2)
+
+		if len(got) != 1 || got[0] != 1 {
+			t.Error("Expected", []int{1}, "got", got)
+		}
+	})
+
+	t.Run("manual disconnect", func(t *testing.T) {
+		obj := newTestObject()
+		ctx, cancel := context.WithCancel(context.Background())
+
+		var calls int
+		h := obj.ConnectContext(ctx, "int", func(obj *glib.Object, i int) {
+			calls++
+		})
+		obj.HandlerDisconnect(h)
+		if obj.HandlerIsConnected(h) {
+			t.Error("Expected the handler to be disconnected")
+		}
+
+		testobject.EmitInt(obj.Native(), 1)
+		if calls != 0 {
+			t.Error("Expected", 0, "calls after disconnecting, got", calls)
+		}
+
+		// Disconnecting again, either manually or through ctx, does nothing.
+		// The handler is disconnected through ctx asynchronously, so give it
+		// some time to complain if it's going to.
+		criticals := testobject.CountCriticals(func() {
+			obj.HandlerDisconnect(h)
+			cancel()
+			time.Sleep(10 * time.Millisecond)
+			iterate(func() bool { return !glib.MainContextDefault().Pending() })
+		})
+		if criticals != 0 {
+			t.Error("Expected", 0, "critical warnings, got", criticals)
+		}
+	})
+
+	t.Run("finalized", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		obj := newTestObject()
+		obj.ConnectContext(ctx, "int", func(obj *glib.Object, i int) {})
+
+		if !glib.WaitForFinalization(obj, 5*time.Second) {
+			t.Fatal("Expected the object not to be kept alive by the context")
+		}
+	})
+
+	t.Run("cancel after owner dropped", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+
+		obj := newTestObject()
+		obj.ConnectContext(ctx, "int", func(obj *glib.Object, i int) {})
+
+		if !glib.WaitForFinalization(obj, 5*time.Second) {
+			t.Fatal("Expected the object not to be kept alive by the context")
+		}
+
+		// The object is gone along with its owner's reference, so the idle
+		// source finds nothing to disconnect.
+		criticals := testobject.CountCriticals(func() {
+			cancel()
+			time.Sleep(10 * time.Millisecond)
+			iterate(func() bool { return !glib.MainContextDefault().Pending() })
+		})
+		if criticals != 0 {
+			t.Error("Expected", 0, "critical warnings, got", criticals)
+		}
+	})
+}
+
+func TestSignalHandler(t *testing.T) {
+	obj := newTestObject()
+
+	var got []int
+	h := obj.ConnectHandler("int", func(obj *glib.Object, i int) {
+		got = append(got, i)
+	})
+
+	testobject.EmitInt(obj.Native(), 1)
+
+	h.Block()
+	testobject.EmitInt(obj.Native(), 2)
+
+	h.Unblock()
+	testobject.EmitInt(obj.Native(), 3)
+
+	if !h.IsConnected() {
+		t.Error("Expected the handler to be connected")
+	}
+
+	h.Disconnect()
+	testobject.EmitInt(obj.Native(), 4)
+
+	if h.IsConnected() {
+		t.Error("Expected the handler to be disconnected")
+	}
+
+	// Disconnecting twice, blocking and unblocking do nothing, without GLib
+	// complaining about the missing handler.
+	criticals := testobject.CountCriticals(func() {
+		h.Disconnect()
+		h.Block()
+		h.Unblock()
+	})
+	if criticals != 0 {
+		t.Error("Expected", 0, "critical warnings, got", criticals)
+	}
+
+	expected := []int{1, 3}
+	if len(got) != len(expected) || got[0] != expected[0] || got[1] != expected[1] {
+		t.Error("Expected", expected, "got", got)
+	}
+}
+
+func TestSignalHandlerFinalized(t *testing.T) {
+	obj := newTestObject()
+	h := obj.SignalHandler(obj.Connect("int", func(obj *glib.Object, i int) {}))
+
+	if !glib.WaitForFinalization(obj, 5*time.Second) {
+		t.Fatal("Expected the handler not to keep the object alive")
+	}
+
+	if h.IsConnected() {
+		t.Error("Expected the handler of a finalized object not to be connected")
+	}
+
+	// All of these do nothing.
+	h.Block()
+	h.Unblock()
+	h.Disconnect()
+}
+
+func TestConnectMulti(t *testing.T) {
+	objs := []*glib.Object{newTestObject(), newTestObject(), newTestObject()}
+
+	var emitters []uintptr
+	handles := glib.ConnectMulti(objs, "no-args", func(obj *glib.Object) {
+		emitters = append(emitters, obj.Native())
+	})
+
+	if len(handles) != len(objs) {
+		t.Fatal("Expected", len(objs), "handles, got", len(handles))
+	}
+	for i, handle := range handles {
+		if handle == 0 {
+			t.Error("Expected a valid handle for object", i)
+		}
+	}
+
+	for _, i := range []int{2, 0, 1} {
+		testobject.EmitNoArgs(objs[i].Native())
+	}
+
+	expected := []uintptr{objs[2].Native(), objs[0].Native(), objs[1].Native()}
+	if len(emitters) != len(expected) {
+		t.Fatal("Expected", len(expected), "calls, got", len(emitters))
+	}
+	for i := range expected {
+		if emitters[i] != expected[i] {
+			t.Error("Expected", expected[i], "at", i, "got", emitters[i])
+		}
+	}
+}
+
+func TestConnectOnce(t *testing.T) {
+	obj := newTestObject()
+
+	type args struct {
+		i int
+		s string
+	}
+
+	var got []args
+	obj.ConnectOnce("int-string", func(obj *glib.Object, i int, s string) {
+		got = append(got, args{i, s})
+
+		// Emitting again from within the handler must not call it again.
+		testobject.EmitIntString(obj.Native(), i+1, "reentrant")
+	})
+
+	testobject.EmitIntString(obj.Native(), 1, "one")
+	testobject.EmitIntString(obj.Native(), 2, "two")
+
+	if len(got) != 1 || got[0] != (args{1, "one"}) {
+		t.Error("Expected", []args{{1, "one"}}, "got", got)
+	}
+}
+
+func TestConnectFuncStack(t *testing.T) {
+	var got []int
+	fs := closure.NewFuncStack(func(obj *glib.Object, i int) {
+		got = append(got, i)
+	}, 0)
+
+	objs := []*glib.Object{newTestObject(), newTestObject(), newTestObject()}
+	id := glib.SignalLookup("int", objs[0].TypeFromInstance())
+
+	for _, obj := range objs {
+		if h := obj.ConnectFuncStack(id, 0, fs, false); h == 0 {
+			t.Fatal("Failed to connect")
+		}
+	}
+
+	for i, obj := range objs {
+		testobject.EmitInt(obj.Native(), i)
+	}
+
+	expected := []int{0, 1, 2}
+	if len(got) != len(expected) {
+		t.Fatal("Expected", expected, "got", got)
+	}
+	for i := range expected {
+		if got[i] != expected[i] {
+			t.Error("Expected", expected, "got", got)
+			break
+		}
+	}
+}
+
+func TestHandlerFind(t *testing.T) {
+	obj := newTestObject()
+
+	var calls int
+	onInt := func(obj *glib.Object, i int) { calls++ }
+	onNotify := func() {}
+
+	intHandle := obj.Connect("int", onInt)
+	notifyHandle := obj.Connect("notify::int", onNotify)
+
+	if handle, ok := obj.HandlerFind("int", onInt); !ok || handle != intHandle {
+		t.Error("Expected", intHandle, "got", handle, ok)
+	}
+	if handle, ok := obj.HandlerFind("notify", onNotify); !ok || handle != notifyHandle {
+		t.Error("Expected", notifyHandle, "got", handle, ok)
+	}
+	if _, ok := obj.HandlerFind("notify::string", onNotify); ok {
+		t.Error("Expected no handler for another detail")
+	}
+	if _, ok := obj.HandlerFind("int", onNotify); ok {
+		t.Error("Expected no handler for another func")
+	}
+
+	handle, _ := obj.HandlerFind("int", onInt)
+	obj.HandlerDisconnect(handle)
+
+	testobject.EmitInt(obj.Native(), 1)
+	if calls != 0 {
+		t.Error("Expected the found handler to be disconnected, got", calls, "calls")
+	}
+	if _, ok := obj.HandlerFind("int", onInt); ok {
+		t.Error("Expected no handler after disconnecting")
+	}
+}
+
+func TestHandlerFindClosureIdentity(t *testing.T) {
+	obj := newTestObject()
+
+	// Both closures share the code of the same literal.
+	counter := func(n *int) func(obj *glib.Object, i int) {
+		return func(obj *glib.Object, i int) { *n++ }
+	}
+
+	var first, second int
+	onFirst := counter(&first)
+	onSecond := counter(&second)
+
+	firstHandle := obj.Connect("int", onFirst)
+	secondHandle := obj.Connect("int", onSecond)
+
+	if handle, ok := obj.HandlerFind("int", onSecond); !ok || handle != secondHandle {
+		t.Error("Expected", secondHandle, "got", handle, ok)
+	}
+	if handle, ok := obj.HandlerFind("int", onFirst); !ok || handle != firstHandle {
+		t.Error("Expected", firstHandle, "got", handle, ok)
+	}
+	if _, ok := obj.HandlerFind("int", counter(&first)); ok {
+		t.Error("Expected no handler for a closure that was never connected")
+	}
+
+	// The earliest connection of the same closure is found.
+	obj.Connect("int", onFirst)
+	for i := 0; i < 10; i++ {
+		if handle, _ := obj.HandlerFind("int", onFirst); handle != firstHandle {
+			t.Fatal("Expected", firstHandle, "got", handle)
+		}
+	}
+}
+
+func TestSignalNewv(t *testing.T) {
+	obj := glib.ObjectNew(signalerType)
+
+	if id := glib.SignalLookup("poke", signalerType); id != pokeSignal {
+		t.Error("Expected", pokeSignal, "got", id)
+	}
+
+	var pokes int
+	obj.Connect("poke", func() { pokes++ })
+
+	var pings []string
+	obj.Connect("ping", func(obj *glib.Object, i int, s string) {
+		pings = append(pings, fmt.Sprint(i, s))
+	})
+
+	if _, err := obj.Emit("poke"); err != nil {
+		t.Fatal("Failed to emit poke:", err)
+	}
+	if _, err := obj.Emit("ping", 42, "pong"); err != nil {
+		t.Fatal("Failed to emit ping:", err)
+	}
+
+	if pokes != 1 {
+		t.Error("Expected", 1, "poke, got", pokes)
+	}
+	if len(pings) != 1 || pings[0] != "42pong" {
+		t.Error("Expected", []string{"42pong"}, "got", pings)
+	}
+
+	if _, err := glib.SignalNewv(signalerType, "poke", glib.SIGNAL_RUN_LAST, glib.TYPE_NONE, nil); err == nil {
+		t.Error("Expected an error for a duplicate signal")
+	}
+}
+
+func TestSignalPlatformWidthArgs(t *testing.T) {
+	if strconv.IntSize < 64 {
+		t.Skip("longs are 32-bit on this platform")
+	}
+
+	obj := glib.ObjectNew(signalerType)
+
+	var (
+		gotSize   uint64
+		gotSigned int64
+		gotUint64 uint64
+	)
+	obj.Connect("size", func(obj *glib.Object, size uint64, signed int64, u uint64) {
+		gotSize, gotSigned, gotUint64 = size, signed, u
+	})
+
+	const large = 1<<40 + 1
+	if _, err := obj.Emit("size", uint(large), -large, uint(large)); err != nil {
+		t.Fatal("Failed to emit size:", err)
+	}
+
+	if gotSize != large {
+		t.Error("Expected", uint64(large), "got", gotSize)
+	}
+	if gotSigned != -large {
+		t.Error("Expected", int64(-large), "got", gotSigned)
+	}
+	if gotUint64 != large {
+		t.Error("Expected", uint64(large), "got", gotUint64)
+	}
+
+	if _, err := obj.Emit("size", -1, 0, 0); err == nil {
+		t.Error("Expected an error for a negative size")
+	}
+}
@@ -0,0 +1,410 @@
+package glib
+
+// #include <glib.h>
+// #include <glib-object.h>
+// #include "glib.go.h"
+import "C"
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"unsafe"
+
+	"github.com/diamondburned/go-glib/core/callback"
+	"github.com/diamondburned/go-glib/core/closure"
+)
+
+/*
+ * GBinding
+ */
+
+// BindingFlags is a representation of GLib's GBindingFlags.
+type BindingFlags int
+
+const (
+	BINDING_DEFAULT        BindingFlags = C.G_BINDING_DEFAULT
+	BINDING_BIDIRECTIONAL  BindingFlags = C.G_BINDING_BIDIRECTIONAL
+	BINDING_SYNC_CREATE    BindingFlags = C.G_BINDING_SYNC_CREATE
+	BINDING_INVERT_BOOLEAN BindingFlags = C.G_BINDING_INVERT_BOOLEAN
+)
+
+// Binding is a representation of GLib's GBinding. It holds a reference on the
+// GBinding, so it stays valid after being unbound.
+type Binding struct {
+	*Object
+	unbound int32
+}
+
+func wrapBinding(ptr unsafe.Pointer) *Binding {
+	obj := Take(ptr)
+	if obj == nil {
+		return nil
+	}
+	return &Binding{Object: obj}
+}
+
+// native returns a pointer to the underlying GBinding.
+func (v *Binding) native() *C.GBinding {
+	if v == nil || v.Object == nil {
+		return nil
+	}
+	return C.toGBinding(unsafe.Pointer(v.GObject))
+}
+
+// Unbind is a wrapper around g_binding_unbind(). It releases the reference
+// that the bound objects hold on the binding, so only the first call does
+// anything; older versions of GLib would release it again otherwise.
+func (v *Binding) Unbind() {
+	if atomic.CompareAndSwapInt32(&v.unbound, 0, 1) {
+		C.g_binding_unbind(v.native())
+	}
+}
+
+// Source returns the source object of the binding, which is nil once the
+// source has been finalized or the binding unbound. It reads the "source"
+// property rather than using g_binding_get_source(), which doesn't hold a
+// reference on the object it returns.
+func (v *Binding) Source() *Object {
+	return v.objectProperty("source")
+}
+
+// Target returns the target object of the binding, which is nil once the
+// target has been finalized or the binding unbound. See Source.
+func (v *Binding) Target() *Object {
+	return v.objectProperty("target")
+}
+
+// objectProperty returns the value of the object property of the binding with
+// the given name.
+func (v *Binding) objectProperty(name string) *Object {
+	value, err := ValueInit(TYPE_OBJECT)
+	if err != nil {
+		return nil
+	}
+
+	cname := (*C.gchar)(C.CString(name))
+	defer C.free(unsafe.Pointer(cname))
+
+	C.g_object_get_property(v.GObject, cname, value.native())
+
+	obj, _ := value.GetObject()
+	return obj
+}
+
+// SourceProperty is a wrapper around g_binding_get_source_property().
+func (v *Binding) SourceProperty() string {
+	return C.GoString((*C.char)(C.g_binding_get_source_property(v.native())))
+}
+
+// TargetProperty is a wrapper around g_binding_get_target_property().
+func (v *Binding) TargetProperty() string {
+	return C.GoString((*C.char)(C.g_binding_get_target_property(v.native())))
+}
+
+// Flags is a wrapper around g_binding_get_flags().
+func (v *Binding) Flags() BindingFlags {
+	return BindingFlags(C.g_binding_get_flags(v.native()))
+}
+
+// BindProperty is a wrapper around g_object_bind_property(). It binds
+// sourceProp of source to targetProp of target, so that the target property is
+// set whenever the source property changes, and the other way around too if
+// flags include BINDING_BIDIRECTIONAL. Nil is returned if the properties can't
+// be bound, such as if either doesn't exist.
+func BindProperty(source *Object, sourceProp string, target *Object, targetProp string, flags BindingFlags) *Binding {
+	return bindProperty(source, sourceProp, target, targetProp, flags)
+}
+
+// bindProperty is a wrapper around g_object_bind_property(). Nil is returned
+// if the binding could not be created.
+func bindProperty(source *Object, sourceProp string, target *Object, targetProp string, flags BindingFlags) *Binding {
+	// Check the properties beforehand, since GLib only prints a critical
+	// warning for missing ones.
+	if source.findProperty(sourceProp) == nil || target.findProperty(targetProp) == nil {
+		return nil
+	}
+
+	csource := (*C.gchar)(C.CString(sourceProp))
+	defer C.free(unsafe.Pointer(csource))
+
+	ctarget := (*C.gchar)(C.CString(targetProp))
+	defer C.free(unsafe.Pointer(ctarget))
+
+	c := C.g_object_bind_property(
+		C.gpointer(source.native()), csource,
+		C.gpointer(target.native()), ctarget,
+		C.GBindingFlags(flags),
+	)
+	if c == nil {
+		return nil
+	}
+
+	return wrapBinding(unsafe.Pointer(c))
+}
+
+// bindingTransforms holds the transform functions of a binding created by
+// BindPropertyFull. The functions are released as soon as either bound object
+// is finalized, since the binding can't call them anymore, even if the Binding
+// is still referenced. The rest is freed once the binding is finalized.
+type bindingTransforms struct {
+	mu       sync.Mutex
+	to, from func(from *Value, to *Value) bool
+
+	// guard is shared by both directions, like in BindPropertyNamed.
+	guard transformGuard
+
+	// objects are weak references to the source and target, and notifies are
+	// the callback IDs of their weak notifies, which call release.
+	objects  [2]*C.GWeakRef
+	notifies [2]C.gpointer
+}
+
+// watch sets up the weak notifies of the source and target.
+func (t *bindingTransforms) watch(source, target *Object) {
+	for i, obj := range [2]*Object{source, target} {
+		t.objects[i] = (*C.GWeakRef)(C.g_malloc0(C.sizeof_GWeakRef))
+		C.g_weak_ref_init(t.objects[i], C.gpointer(obj.native()))
+
+		t.notifies[i] = C.gpointer(callback.Assign(t.release))
+		C.g_object_weak_ref(obj.native(), (*[0]byte)(C.goWeakNotify), t.notifies[i])
+	}
+}
+
+// release drops the transform functions.
+func (t *bindingTransforms) release() {
+	t.mu.Lock()
+	t.to, t.from = nil, nil
+	t.mu.Unlock()
+}
+
+// free releases the functions and removes the weak notifies of the objects
+// that are still alive. The GWeakRefs of objects are cleared before their weak
+// notifies run, so those of objects being finalized are left to run, which
+// only releases the functions again.
+func (t *bindingTransforms) free() {
+	t.release()
+
+	for i, weakRef := range t.objects {
+		if obj := C.g_weak_ref_get(weakRef); obj != nil {
+			C.g_object_weak_unref((*C.GObject)(obj), (*[0]byte)(C.goWeakNotify), t.notifies[i])
+			callback.Delete(uintptr(t.notifies[i]))
+			C.g_object_unref(obj)
+		}
+
+		C.g_weak_ref_clear(weakRef)
+		C.g_free(C.gpointer(weakRef))
+	}
+}
+
+// BindPropertyFull is a wrapper around g_object_bind_property_full(). It works
+// like BindProperty, but transformTo converts the value of the source property
+// before it is set on the target, and transformFrom does the same the other way
+// around for bidirectional bindings. Either function may be nil, in which case
+// the values are transformed with the default GValue transformations. The
+// functions return false if the value couldn't be converted, which leaves the
+// other property untouched. Like with BindPropertyNamed, neither function runs
+// while one of them already is.
+//
+// The functions are released once either object is finalized or the binding
+// is, whichever comes first.
+func BindPropertyFull(source *Object, sourceProp string, target *Object, targetProp string, flags BindingFlags, transformTo, transformFrom func(from *Value, to *Value) bool) *Binding {
+	if source.findProperty(sourceProp) == nil || target.findProperty(targetProp) == nil {
+		return nil
+	}
+
+	csource := (*C.gchar)(C.CString(sourceProp))
+	defer C.free(unsafe.Pointer(csource))
+
+	ctarget := (*C.gchar)(C.CString(targetProp))
+	defer C.free(unsafe.Pointer(ctarget))
+
+	transforms := &bindingTransforms{
+		to:   transformTo,
+		from: transformFrom,
+	}
+
+	// The functions are freed by goBindingTransformDestroy once the binding is
+	// finalized.
+	id := C.gpointer(callback.Assign(transforms))
+
+	c := C._g_object_bind_property_full(
+		C.gpointer(source.native()), csource,
+		C.gpointer(target.native()), ctarget,
+		C.GBindingFlags(flags),
+		gbool(transformTo != nil), gbool(transformFrom != nil),
+		id,
+	)
+	if c == nil {
+		// GLib doesn't call the destroy notify if it bails out early.
+		callback.Delete(uintptr(id))
+		return nil
+	}
+
+	binding := wrapBinding(unsafe.Pointer(c))
+	transforms.watch(source, target)
+
+	return binding
+}
+
+//export goBindingTransformTo
+func goBindingTransformTo(_ *C.GBinding, from, to *C.GValue, data C.gpointer) C.gboolean {
+	transforms := callback.Get(uintptr(data)).(*bindingTransforms)
+
+	transforms.mu.Lock()
+	f := transforms.to
+	transforms.mu.Unlock()
+
+	if f == nil || !transforms.guard.enter() {
+		return C.FALSE
+	}
+	defer transforms.guard.leave()
+
+	return gbool(f(&Value{from}, &Value{to}))
+}
+
+//export goBindingTransformFrom
+func goBindingTransformFrom(_ *C.GBinding, from, to *C.GValue, data C.gpointer) C.gboolean {
+	transforms := callback.Get(uintptr(data)).(*bindingTransforms)
+
+	transforms.mu.Lock()
+	f := transforms.from
+	transforms.mu.Unlock()
+
+	if f == nil || !transforms.guard.enter() {
+		return C.FALSE
+	}
+	defer transforms.guard.leave()
+
+	return gbool(f(&Value{from}, &Value{to}))
+}
+
+//export goBindingTransformDestroy
+func goBindingTransformDestroy(data C.gpointer) {
+	callback.GetAndDelete(uintptr(data)).(*bindingTransforms).free()
+}
+
+// BindProperties binds multiple properties of v to properties of target with
+// the same flags. pairs maps the names of v's properties to the names of the
+// target's properties. The created bindings are returned in the order of their
+// source property names, so they can be unbound together. Pairs that could not
+// be bound are skipped.
+func (v *Object) BindProperties(target *Object, pairs map[string]string, flags BindingFlags) []*Binding {
+	sourceProps := make([]string, 0, len(pairs))
+	for sourceProp := range pairs {
+		sourceProps = append(sourceProps, sourceProp)
+	}
+	sort.Strings(sourceProps)
+
+	bindings := make([]*Binding, 0, len(pairs))
+	for _, sourceProp := range sourceProps {
+		binding := bindProperty(v, sourceProp, target, pairs[sourceProp], flags)
+		if binding != nil {
+			bindings = append(bindings, binding)
+		}
+	}
+
+	return bindings
+}
+
+// BindPropertyNamed is a wrapper around g_object_bind_property_with_closures().
+// It binds sourceProp of v to targetProp of target, converting the values with
+// the functions registered using RegisterClosure under the given names. The
+// names may be empty to convert the values using the default transformations
+// instead.
+//
+// transformToName converts from sourceProp to targetProp, and transformFromName
+// the other way around, which is only used by bidirectional bindings. The
+// functions must have the following signature:
+//
+//	func(binding *Object, from, to *Value) bool
+//
+// to is initialized to the type of the property being set, and the function
+// returns false if from could not be converted. The binding parameter may be
+// omitted.
+func (v *Object) BindPropertyNamed(sourceProp string, target *Object, targetProp string, transformToName, transformFromName string, flags BindingFlags) (*Binding, error) {
+	transformToFunc, err := namedClosure(transformToName)
+	if err != nil {
+		return nil, err
+	}
+
+	transformFromFunc, err := namedClosure(transformFromName)
+	if err != nil {
+		return nil, err
+	}
+
+	// Both directions share the guard, so that neither transform can cause
+	// the other one to run while it's setting a property.
+	guard := new(transformGuard)
+
+	var transformTo, transformFrom *C.GClosure
+	if transformToFunc != nil {
+		transformTo = v.ClosureNew(guard.wrap(transformToFunc))
+	}
+	if transformFromFunc != nil {
+		transformFrom = v.ClosureNew(guard.wrap(transformFromFunc))
+	}
+
+	csource := (*C.gchar)(C.CString(sourceProp))
+	defer C.free(unsafe.Pointer(csource))
+
+	ctarget := (*C.gchar)(C.CString(targetProp))
+	defer C.free(unsafe.Pointer(ctarget))
+
+	c := C.g_object_bind_property_with_closures(
+		C.gpointer(v.native()), csource,
+		C.gpointer(target.native()), ctarget,
+		C.GBindingFlags(flags),
+		transformTo, transformFrom,
+	)
+	if c == nil {
+		return nil, errors.New("failed to bind property")
+	}
+
+	return wrapBinding(unsafe.Pointer(c)), nil
+}
+
+// transformGuard keeps the transform functions of a binding from running while
+// one of them already is. GLib already ignores the notifications caused by the
+// binding setting a property, but a transform function may also set properties
+// itself, such as to normalize the source value, which would otherwise notify
+// the binding again from within the transform and make the two properties
+// bounce between each other.
+type transformGuard struct {
+	syncing int32
+}
+
+// enter reports whether no transform function is running, in which case the
+// caller may run one and must call leave once it's done.
+func (g *transformGuard) enter() bool {
+	return atomic.CompareAndSwapInt32(&g.syncing, 0, 1)
+}
+
+// leave marks the transform function started after enter as done.
+func (g *transformGuard) leave() {
+	atomic.StoreInt32(&g.syncing, 0)
+}
+
+// wrap returns a FuncStack calling the transform function in fs, unless the
+// guard is already syncing, in which case the transform reports failure by
+// returning zero values, so the property isn't set.
+func (g *transformGuard) wrap(fs *closure.FuncStack) *closure.FuncStack {
+	fsType := fs.Func.Type()
+
+	wrapped := reflect.MakeFunc(fsType, func(args []reflect.Value) []reflect.Value {
+		if !g.enter() {
+			out := make([]reflect.Value, fsType.NumOut())
+			for i := range out {
+				out[i] = reflect.Zero(fsType.Out(i))
+			}
+			return out
+		}
+		defer g.leave()
+
+		return fs.Func.Call(args)
+	})
+
+	return &closure.FuncStack{Func: wrapped, Frames: fs.Frames}
+}
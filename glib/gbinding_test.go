@@ -0,0 +1,331 @@
+// Same copyright and license as the rest of the files in this project
+
+package glib_test
+
+import (
+	"fmt"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/diamondburned/go-glib/glib"
+)
+
+func TestBindProperties(t *testing.T) {
+	source := newTestObject()
+	target := newTestObject()
+
+	bindings := source.BindProperties(target, map[string]string{
+		"int":     "int",
+		"string":  "string",
+		"boolean": "boolean",
+	}, glib.BINDING_DEFAULT)
+
+	if len(bindings) != 3 {
+		t.Fatal("Expected", 3, "bindings, got", len(bindings))
+	}
+
+	source.SetProperty("int", 42)
+	source.SetProperty("string", "bound")
+	source.SetProperty("boolean", true)
+
+	expectProperties(t, target, map[string]interface{}{
+		"int":     42,
+		"string":  "bound",
+		"boolean": true,
+	})
+
+	for _, binding := range bindings {
+		binding.Unbind()
+	}
+
+	source.SetProperty("int", 7)
+	source.SetProperty("string", "unbound")
+	source.SetProperty("boolean", false)
+
+	expectProperties(t, target, map[string]interface{}{
+		"int":     42,
+		"string":  "bound",
+		"boolean": true,
+	})
+}
+
+func TestBindProperty(t *testing.T) {
+	source := newTestObject()
+	target := newTestObject()
+
+	binding := glib.BindProperty(source, "boolean", target, "boolean", glib.BINDING_BIDIRECTIONAL)
+	if binding == nil {
+		t.Fatal("Failed to bind properties")
+	}
+
+	source.SetProperty("boolean", true)
+	expectProperties(t, target, map[string]interface{}{"boolean": true})
+
+	target.SetProperty("boolean", false)
+	expectProperties(t, source, map[string]interface{}{"boolean": false})
+
+	binding.Unbind()
+	// Unbinding again must be harmless.
+	binding.Unbind()
+
+	source.SetProperty("boolean", true)
+	expectProperties(t, target, map[string]interface{}{"boolean": false})
+
+	t.Run("invert sync create", func(t *testing.T) {
+		source := newTestObject()
+		target := newTestObject()
+
+		binding := glib.BindProperty(source, "boolean", target, "boolean",
+			glib.BINDING_SYNC_CREATE|glib.BINDING_INVERT_BOOLEAN)
+		defer binding.Unbind()
+
+		expectProperties(t, target, map[string]interface{}{"boolean": true})
+	})
+
+	if binding := glib.BindProperty(source, "missing", target, "boolean", glib.BINDING_DEFAULT); binding != nil {
+		t.Error("Expected no binding for a missing property")
+	}
+}
+
+func TestBindingIntrospection(t *testing.T) {
+	source := newTestObject()
+	target := newTestObject()
+
+	flags := glib.BINDING_BIDIRECTIONAL | glib.BINDING_SYNC_CREATE
+	binding := glib.BindProperty(source, "int", target, "double", flags)
+	if binding == nil {
+		t.Fatal("Failed to bind properties")
+	}
+
+	if got := binding.Source(); got == nil || got.Native() != source.Native() {
+		t.Error("Expected", source, "got", got)
+	}
+	if got := binding.Target(); got == nil || got.Native() != target.Native() {
+		t.Error("Expected", target, "got", got)
+	}
+	if got := binding.SourceProperty(); got != "int" {
+		t.Error("Expected", "int", "got", got)
+	}
+	if got := binding.TargetProperty(); got != "double" {
+		t.Error("Expected", "double", "got", got)
+	}
+	if got := binding.Flags(); got != flags {
+		t.Error("Expected", flags, "got", got)
+	}
+
+	binding.Unbind()
+
+	if got := binding.Source(); got != nil {
+		t.Error("Expected nil source after unbinding, got", got)
+	}
+}
+
+func TestBindPropertyFull(t *testing.T) {
+	source := newTestObject()
+	target := newTestObject()
+
+	source.SetProperty("int", 3)
+
+	binding := glib.BindPropertyFull(source, "int", target, "string", glib.BINDING_SYNC_CREATE,
+		func(from, to *glib.Value) bool {
+			i, err := from.GetInt()
+			if err != nil {
+				return false
+			}
+			to.SetString(fmt.Sprintf("%d items", i))
+			return true
+		},
+		nil,
+	)
+	if binding == nil {
+		t.Fatal("Failed to bind properties")
+	}
+
+	expectProperties(t, target, map[string]interface{}{"string": "3 items"})
+
+	source.SetProperty("int", 42)
+	expectProperties(t, target, map[string]interface{}{"string": "42 items"})
+
+	binding.Unbind()
+
+	source.SetProperty("int", 7)
+	expectProperties(t, target, map[string]interface{}{"string": "42 items"})
+
+	if binding := glib.BindPropertyFull(source, "missing", target, "string", glib.BINDING_DEFAULT, nil, nil); binding != nil {
+		t.Error("Expected no binding for a missing property")
+	}
+}
+
+func TestBindPropertyFullConverges(t *testing.T) {
+	source := newTestObject()
+	target := newTestObject()
+
+	var calls int
+
+	// The transforms don't round-trip, and each sets the property it's
+	// transforming from again, as if normalizing it, which notifies the
+	// binding from within the transform.
+	transform := func(obj *glib.Object, delta int) func(from, to *glib.Value) bool {
+		return func(from, to *glib.Value) bool {
+			if calls++; calls > 100 {
+				return false
+			}
+
+			v, err := from.GetInt()
+			if err != nil {
+				return false
+			}
+			obj.SetProperty("int", v)
+
+			to.SetInt(v + delta)
+			return true
+		}
+	}
+
+	binding := glib.BindPropertyFull(source, "int", target, "int", glib.BINDING_BIDIRECTIONAL,
+		transform(source, 1), transform(target, -1))
+	if binding == nil {
+		t.Fatal("Failed to bind properties")
+	}
+	defer binding.Unbind()
+
+	source.SetProperty("int", 5)
+	expectProperties(t, source, map[string]interface{}{"int": 5})
+	expectProperties(t, target, map[string]interface{}{"int": 6})
+
+	if calls > 2 {
+		t.Error("Expected the binding to converge, got", calls, "transforms")
+	}
+}
+
+func TestBindPropertyFullSourceFinalized(t *testing.T) {
+	source := newTestObject()
+	target := newTestObject()
+
+	// The marker is only referenced by the transform function, so it can only
+	// be finalized once the function is released.
+	marker := glib.ObjectNew(glib.TYPE_OBJECT)
+	binding := glib.BindPropertyFull(source, "int", target, "string", glib.BINDING_DEFAULT,
+		func(from, to *glib.Value) bool {
+			runtime.KeepAlive(marker)
+			return false
+		},
+		nil,
+	)
+	if binding == nil {
+		t.Fatal("Failed to bind properties")
+	}
+
+	if !glib.WaitForFinalization(source, 5*time.Second) {
+		t.Fatal("Expected the binding not to keep the source alive")
+	}
+
+	// The binding is still referenced, but the function must not be.
+	if !glib.WaitForFinalization(marker, 5*time.Second) {
+		t.Error("Expected the transform function to be released")
+	}
+
+	runtime.KeepAlive(binding)
+	runtime.KeepAlive(target)
+}
+
+func TestBindPropertyNamed(t *testing.T) {
+	glib.RegisterClosure("go-glib-test-double", func(binding *glib.Object, from, to *glib.Value) bool {
+		v, _ := from.GoValue()
+		to.SetInt(v.(int) * 2)
+		return true
+	})
+	glib.RegisterClosure("go-glib-test-half", func(binding *glib.Object, from, to *glib.Value) bool {
+		v, _ := from.GoValue()
+		to.SetInt(v.(int) / 2)
+		return true
+	})
+
+	source := newTestObject()
+	target := newTestObject()
+
+	binding, err := source.BindPropertyNamed(
+		"int", target, "int",
+		"go-glib-test-double", "go-glib-test-half",
+		glib.BINDING_BIDIRECTIONAL,
+	)
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	defer binding.Unbind()
+
+	source.SetProperty("int", 21)
+	expectProperties(t, target, map[string]interface{}{"int": 42})
+
+	target.SetProperty("int", 10)
+	expectProperties(t, source, map[string]interface{}{"int": 5})
+
+	if _, err := source.BindPropertyNamed("string", target, "string", "missing", "", glib.BINDING_DEFAULT); err == nil {
+		t.Error("Expected error for unregistered closure")
+	}
+}
+
+func TestBindPropertyNamedConverges(t *testing.T) {
+	var calls int
+
+	// Each transform sets the property it's transforming from again, as if
+	// normalizing it, which notifies the binding from within the transform.
+	transform := func(delta int) func(binding *glib.Object, from, to *glib.Value) bool {
+		return func(binding *glib.Object, from, to *glib.Value) bool {
+			if calls++; calls > 100 {
+				return false
+			}
+
+			v, _ := from.GoValue()
+
+			prop := "source"
+			if delta < 0 {
+				prop = "target"
+			}
+			obj, _ := binding.GetProperty(prop)
+			obj.(*glib.Object).SetProperty("int", v)
+
+			to.SetInt(v.(int) + delta)
+			return true
+		}
+	}
+	glib.RegisterClosure("go-glib-test-increment", transform(1))
+	glib.RegisterClosure("go-glib-test-decrement", transform(-1))
+
+	source := newTestObject()
+	target := newTestObject()
+
+	binding, err := source.BindPropertyNamed(
+		"int", target, "int",
+		"go-glib-test-increment", "go-glib-test-decrement",
+		glib.BINDING_BIDIRECTIONAL,
+	)
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	defer binding.Unbind()
+
+	source.SetProperty("int", 5)
+	expectProperties(t, source, map[string]interface{}{"int": 5})
+	expectProperties(t, target, map[string]interface{}{"int": 6})
+
+	if calls > 2 {
+		t.Error("Expected the binding to converge, got", calls, "transforms")
+	}
+}
+
+func expectProperties(t *testing.T, obj *glib.Object, expected map[string]interface{}) {
+	t.Helper()
+
+	for name, value := range expected {
+		actual, err := obj.GetProperty(name)
+		if err != nil {
+			t.Error("Unexpected error getting", name+":", err)
+			continue
+		}
+		if actual != value {
+			t.Error("Expected", name, "to be", value, "got", actual)
+		}
+	}
+}
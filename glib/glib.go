@@ -27,11 +27,18 @@ package glib
 import "C"
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"log"
 	"reflect"
 	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 	"unsafe"
 
 	"github.com/diamondburned/go-glib/core/callback"
@@ -57,6 +64,83 @@ func gobool(b C.gboolean) bool {
 	return false
 }
 
+/*
+ * Errors
+ */
+
+// TakeError converts the given GError to a Go error and frees it. Nil is
+// returned if ptr is nil. The error is a *GError. This function is exported
+// for visibility in other gotk3 packages and is not meant to be used by
+// applications.
+func TakeError(ptr unsafe.Pointer) error {
+	return newGError((*C.GError)(ptr))
+}
+
+// GError is a representation of GLib's GError. It holds a copy of the domain,
+// code and message of the GError, so it stays valid after the GError is freed.
+// GErrors of cancelled GIO operations match context.Canceled when using
+// errors.Is.
+type GError struct {
+	domain  Quark
+	code    int
+	message string
+}
+
+// newGError converts gerr to a Go error and frees it. Nil is returned if gerr
+// is nil.
+func newGError(gerr *C.GError) error {
+	if gerr == nil {
+		return nil
+	}
+	return takeGError(gerr)
+}
+
+// takeGError converts gerr, which must not be nil, to a GError and frees it.
+func takeGError(gerr *C.GError) *GError {
+	defer C.g_error_free(gerr)
+
+	return &GError{
+		domain:  Quark(gerr.domain),
+		code:    int(gerr.code),
+		message: C.GoString((*C.char)(gerr.message)),
+	}
+}
+
+// Error returns the message of the GError.
+func (err *GError) Error() string {
+	return err.message
+}
+
+// Domain returns the string of the quark of the error domain, such as
+// "g-io-error-quark".
+func (err *GError) Domain() string {
+	return C.GoString((*C.char)(C.g_quark_to_string(C.GQuark(err.domain))))
+}
+
+// Code returns the error code, whose meaning depends on the domain.
+func (err *GError) Code() int {
+	return err.code
+}
+
+// Matches is like g_error_matches(). It returns true if the error has the
+// given domain, as the string of its quark, and code.
+func (err *GError) Matches(domain string, code int) bool {
+	cdomain := (*C.gchar)(C.CString(domain))
+	defer C.free(unsafe.Pointer(cdomain))
+
+	quark := C.g_quark_try_string(cdomain)
+	return quark != 0 && Quark(quark) == err.domain && code == err.code
+}
+
+// Unwrap returns context.Canceled for GErrors of cancelled GIO operations, and
+// nil otherwise.
+func (err *GError) Unwrap() error {
+	if C.GQuark(err.domain) == C.g_io_error_quark() && err.code == C.G_IO_ERROR_CANCELLED {
+		return context.Canceled
+	}
+	return nil
+}
+
 /*
  * Unexported vars
  */
@@ -100,9 +184,17 @@ func (t Type) IsValue() bool {
 	return gobool(C._g_type_is_value(C.GType(t)))
 }
 
-// Name is a wrapper around g_type_name().
+// Name is a wrapper around g_type_name(). An empty string is returned for
+// TYPE_INVALID and for types that aren't registered.
 func (t Type) Name() string {
-	return C.GoString((*C.char)(C.g_type_name(C.GType(t))))
+	if t == TYPE_INVALID {
+		return ""
+	}
+	c := C.g_type_name(C.GType(t))
+	if c == nil {
+		return ""
+	}
+	return C.GoString((*C.char)(c))
 }
 
 // Depth is a wrapper around g_type_depth().
@@ -110,7 +202,9 @@ func (t Type) Depth() uint {
 	return uint(C.g_type_depth(C.GType(t)))
 }
 
-// Parent is a wrapper around g_type_parent().
+// Parent is a wrapper around g_type_parent(). It returns TYPE_INVALID for
+// fundamental types, such as TYPE_OBJECT, which ends the walk up the hierarchy
+// of a type.
 func (t Type) Parent() Type {
 	return Type(C.g_type_parent(C.GType(t)))
 }
@@ -120,7 +214,41 @@ func (t Type) IsA(isAType Type) bool {
 	return gobool(C.g_type_is_a(C.GType(t), C.GType(isAType)))
 }
 
-// TypeFromName is a wrapper around g_type_from_name
+// Ancestry returns t followed by its parents, up to and including its
+// fundamental type. It's empty for TYPE_INVALID.
+func (t Type) Ancestry() []Type {
+	ancestry := make([]Type, 0, t.Depth())
+	for ; t != TYPE_INVALID; t = t.Parent() {
+		ancestry = append(ancestry, t)
+	}
+	return ancestry
+}
+
+// Children is a wrapper around g_type_children(). It returns the types that
+// are directly derived from t.
+func (t Type) Children() []Type {
+	var n C.guint
+	list := C.g_type_children(C.GType(t), &n)
+	defer C.g_free(C.gpointer(list))
+
+	children := make([]Type, n)
+	for i, child := range gtypeSlice(list, int(n)) {
+		children[i] = Type(child)
+	}
+	return children
+}
+
+// gtypeSlice converts a C array of GTypes to a Go slice.
+func gtypeSlice(types *C.GType, nTypes int) (slice []C.GType) {
+	header := (*reflect.SliceHeader)((unsafe.Pointer(&slice)))
+	header.Cap = nTypes
+	header.Len = nTypes
+	header.Data = uintptr(unsafe.Pointer(types))
+	return
+}
+
+// TypeFromName is a wrapper around g_type_from_name. It returns TYPE_INVALID if
+// no type of the given name is registered.
 func TypeFromName(typeName string) Type {
 	cstr := (*C.gchar)(C.CString(typeName))
 	defer C.free(unsafe.Pointer(cstr))
@@ -196,31 +324,113 @@ func goMarshal(
 		return
 	}
 
+	marshalClosure(fs, retValue, nParams, params, invocationHint, gobject)
+}
+
+// goMarshalUnsafe is the marshaler of closures connected using ConnectUnsafe,
+// whose callbacks are found in the arena of the object using the index stored
+// as the closure's data.
+//
+//export goMarshalUnsafe
+func goMarshalUnsafe(
+	gclosure *C.GClosure,
+	retValue *C.GValue,
+	nParams C.guint,
+	params *C.GValue,
+	invocationHint C.gpointer,
+	gobject *C.GObject) {
+
+	fs := intern.ObjectArenaClosure(unsafe.Pointer(gobject), int(uintptr(gclosure.data)))
+	if fs == nil {
+		return
+	}
+
+	marshalClosure(fs, retValue, nParams, params, invocationHint, gobject)
+}
+
+// marshalClosure calls the callback in fs with the given parameters.
+func marshalClosure(
+	fs *closure.FuncStack,
+	retValue *C.GValue,
+	nParams C.guint,
+	params *C.GValue,
+	invocationHint C.gpointer,
+	gobject *C.GObject) {
+
+	countEmission(gobject, (*C.GSignalInvocationHint)(invocationHint))
+
 	fsType := fs.Func.Type()
 
+	// Reflect may panic, so we defer recover here to re-panic with our trace.
+	defer fs.TryRepanic()
+
+	if fsType == rawHandlerType {
+		marshalRaw(fs, retValue, nParams, params)
+		return
+	}
+
 	// Get number of parameters passed in.
 	nGLibParams := int(nParams)
 	nTotalParams := nGLibParams
 
-	// Reflect may panic, so we defer recover here to re-panic with our trace.
-	defer fs.TryRepanic()
-
 	// Get number of parameters from the callback closure. If this exceeds
 	// the total number of marshaled parameters, trigger a runtime panic.
 	nCbParams := fsType.NumIn()
-	if nCbParams > nTotalParams {
-		fs.Panicf("too many closure args: have %d, max %d", nCbParams, nTotalParams)
+	checkArgCount(fs, nTotalParams)
+
+	// Borrow a slice of reflect.Values as arguments to call the function. The
+	// slice is only returned to the pool once the call is done, so reentrant
+	// emissions will borrow their own.
+	nValues := nCbParams
+	if nValues > nGLibParams {
+		nValues = nGLibParams
 	}
+	gValues := gValueSlice(params, nValues)
+	argsPtr := getArgs(nCbParams)
+	defer putArgs(argsPtr)
 
-	// Create a slice of reflect.Values as arguments to call the function.
-	gValues := gValueSlice(params, nCbParams)
-	args := make([]reflect.Value, 0, nCbParams)
+	args := *argsPtr
 
 	// Fill beginning of args, up to the minimum of the total number of callback
 	// parameters and parameters from the glib runtime.
 	for i := 0; i < nCbParams && i < nGLibParams; i++ {
 		v := Value{&gValues[i]}
 
+		// Enum parameters declared as strings receive the nick of the enum
+		// value instead of its integer, which is more useful for logging.
+		if fsType.In(i).Kind() == reflect.String {
+			if nick, ok := v.enumNick(); ok {
+				args[i] = reflect.ValueOf(nick).Convert(fsType.In(i))
+				continue
+			}
+		}
+
+		// GPtrArrays of objects may be received as a slice of objects.
+		if fsType.In(i) == objectSliceType {
+			if objs, ok := v.objectSlice(); ok {
+				args[i] = reflect.ValueOf(objs)
+				continue
+			}
+		}
+
+		// GParamSpecs, such as the one given to "notify", are received as
+		// ParamSpecs.
+		if fsType.In(i) == paramSpecType {
+			if pspec, ok := v.paramSpec(); ok {
+				args[i] = reflect.ValueOf(pspec)
+				continue
+			}
+		}
+
+		// GValues boxed in GValues, such as the ones given to the transform
+		// closures of bindings, are received as Values.
+		if fsType.In(i) == valueType {
+			if inner, ok := v.boxedValue(); ok {
+				args[i] = reflect.ValueOf(inner)
+				continue
+			}
+		}
+
 		val, err := v.GoValue()
 		if err != nil {
 			fs.Panicf("no suitable Go value for arg %d: %v", i, err)
@@ -265,28 +475,184 @@ func goMarshal(
 			}
 		}
 
-		args = append(args, reflect.ValueOf(val).Convert(fsType.In(i)))
+		args[i] = reflect.ValueOf(val).Convert(fsType.In(i))
+	}
+
+	// Only reached with WarnOnExtraArgs set, otherwise we would have panicked.
+	for i := nGLibParams; i < nCbParams; i++ {
+		args[i] = reflect.Zero(fsType.In(i))
 	}
 
 	// Call closure with args. If the callback returns one or more values, save
-	// the GValue equivalent of the first.
+	// the GValue equivalent of the first. A nil return value leaves retValue
+	// untouched, which holds NULL for the signals of pointer types, so that
+	// the accumulator of the signal can tell that the handler returned nothing.
 	rv := fs.Func.Call(args)
-	if retValue != nil && len(rv) > 0 {
+	if retValue != nil && len(rv) > 0 && !isNilValue(rv[0]) {
 		g, err := GValue(rv[0].Interface())
 		if err != nil {
 			fs.Panicf("cannot save callback return value: %v", err)
 		}
 
-		t, _, err := g.Type()
-		if err != nil {
-			fs.Panicf("cannot determine callback return value: %v", err)
+		setReturnValue(fs, retValue, g)
+	}
+}
+
+// isNilValue returns true if v is a nil pointer, interface or other nillable
+// value.
+func isNilValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface, reflect.Map, reflect.Slice, reflect.Func, reflect.Chan, reflect.UnsafePointer:
+		return v.IsNil()
+	}
+	return false
+}
+
+// checkArgCount panics if the callback in fs has more parameters than the
+// nParams given to it, unless WarnOnExtraArgs is set, in which case a warning
+// is logged instead.
+func checkArgCount(fs *closure.FuncStack, nParams int) {
+	nCbParams := fs.Func.Type().NumIn()
+	if nCbParams <= nParams {
+		return
+	}
+
+	if !WarnOnExtraArgs {
+		fs.Panicf("too many closure args: have %d, max %d", nCbParams, nParams)
+	}
+	fs.Warnf("too many closure args: have %d, max %d; passing zero values", nCbParams, nParams)
+}
+
+// setReturnValue saves g as the return value of a closure.
+func setReturnValue(fs *closure.FuncStack, retValue *C.GValue, g *Value) {
+	// Signal emissions initialize the return value to the return type of the
+	// signal, which the Go value might not match exactly, such as an int
+	// returned for a guint. Convert it instead of changing the type, since the
+	// emitter expects the return type of the signal.
+	if C._g_value_type(retValue) != C.G_TYPE_INVALID {
+		if !gobool(C.g_value_transform(g.native(), retValue)) {
+			fs.Panicf("cannot convert callback return value from %s to %s",
+				g.TypeName(), C.GoString((*C.char)(C._g_value_type_name(retValue))))
+		}
+		return
+	}
+
+	t, _, err := g.Type()
+	if err != nil {
+		fs.Panicf("cannot determine callback return value: %v", err)
+	}
+
+	// Explicitly copy the return value as it may point to go-owned memory.
+	C.g_value_init(retValue, C.GType(t))
+	C.g_value_copy(g.native(), retValue)
+}
+
+// rawHandlerType is the type of the callbacks connected using ConnectRaw.
+var rawHandlerType = reflect.TypeOf(func([]*Value) *Value { return nil })
+
+// marshalRaw calls the ConnectRaw callback in fs with the given parameters.
+func marshalRaw(fs *closure.FuncStack, retValue *C.GValue, nParams C.guint, params *C.GValue) {
+	gValues := gValueSlice(params, int(nParams))
+
+	values := make([]*Value, len(gValues))
+	for i := range gValues {
+		values[i] = &Value{&gValues[i]}
+	}
+
+	ret := fs.Func.Interface().(func([]*Value) *Value)(values)
+	if retValue != nil && ret != nil {
+		setReturnValue(fs, retValue, ret)
+	}
+}
+
+// maxPooledArgs is the maximum number of callback parameters that goMarshal
+// will pool argument slices for. Callbacks with more parameters than this are
+// rare, so they allocate a new slice every emission instead.
+const maxPooledArgs = 8
+
+// argsPools contains pools of *[]reflect.Value used by goMarshal, indexed by
+// the length of the slice.
+var argsPools [maxPooledArgs + 1]sync.Pool
+
+// getArgs borrows a slice of n zero reflect.Values. The slice must be returned
+// using putArgs once it's no longer used.
+func getArgs(n int) *[]reflect.Value {
+	if n <= maxPooledArgs {
+		if args, ok := argsPools[n].Get().(*[]reflect.Value); ok {
+			return args
 		}
+	}
+
+	args := make([]reflect.Value, n)
+	return &args
+}
+
+// putArgs zeroes out the given slice and returns it to its pool. Zeroing is
+// done so that the pool doesn't keep the arguments alive, and so they can't
+// leak into the next emission.
+func putArgs(args *[]reflect.Value) {
+	n := len(*args)
+	if n > maxPooledArgs {
+		return
+	}
+
+	for i := range *args {
+		(*args)[i] = reflect.Value{}
+	}
+
+	argsPools[n].Put(args)
+}
+
+// emissionStats counts the signal emissions dispatched by goMarshal while
+// enabled using EnableEmissionStats.
+var emissionStats = struct {
+	enabled int32 // atomic
+	mu      sync.Mutex
+	counts  map[string]uint64
+}{
+	counts: make(map[string]uint64),
+}
+
+// EnableEmissionStats enables or disables counting the signal emissions
+// dispatched to Go callbacks. Counting is disabled by default to avoid its
+// overhead. The counts are kept when disabled. See SignalEmissionStats.
+func EnableEmissionStats(enable bool) {
+	var enabled int32
+	if enable {
+		enabled = 1
+	}
+	atomic.StoreInt32(&emissionStats.enabled, enabled)
+}
+
+// SignalEmissionStats returns a copy of the number of signal emissions
+// dispatched to Go callbacks while counting was enabled, keyed by
+// "TypeName::signal". An emission is counted once per Go callback, so a signal
+// with two connected callbacks counts twice per emission.
+func SignalEmissionStats() map[string]uint64 {
+	emissionStats.mu.Lock()
+	defer emissionStats.mu.Unlock()
+
+	counts := make(map[string]uint64, len(emissionStats.counts))
+	for key, count := range emissionStats.counts {
+		counts[key] = count
+	}
+
+	return counts
+}
 
-		// Explicitly copy the return value as it may point to go-owned memory.
-		C.g_value_unset(retValue)
-		C.g_value_init(retValue, C.GType(t))
-		C.g_value_copy(g.native(), retValue)
+// countEmission counts an emission of the signal in hint on gobject if
+// counting is enabled.
+func countEmission(gobject *C.GObject, hint *C.GSignalInvocationHint) {
+	if atomic.LoadInt32(&emissionStats.enabled) == 0 || hint == nil {
+		return
 	}
+
+	typeName := C.GoString((*C.char)(C.g_type_name(C._g_type_from_instance(C.gpointer(gobject)))))
+	key := typeName + "::" + C.GoString((*C.char)(C.g_signal_name(hint.signal_id)))
+
+	emissionStats.mu.Lock()
+	emissionStats.counts[key]++
+	emissionStats.mu.Unlock()
 }
 
 // gValueSlice converts a C array of GValues to a Go slice.
@@ -321,7 +687,7 @@ type SourceHandle uint
 //export sourceFunc
 func sourceFunc(data C.gpointer) C.gboolean {
 	v := callback.Get(uintptr(data))
-	fs := v.(closure.FuncStack)
+	fs := v.(*closure.FuncStack)
 
 	rv := fs.Func.Call(nil)
 	if len(rv) == 1 && rv[0].Bool() {
@@ -333,77 +699,142 @@ func sourceFunc(data C.gpointer) C.gboolean {
 
 //export removeSourceFunc
 func removeSourceFunc(data C.gpointer) {
+	unregisterSource(uintptr(data))
 	callback.Delete(uintptr(data))
 }
 
-var (
-	_sourceFunc       = (*[0]byte)(C.sourceFunc)
-	_removeSourceFunc = (*[0]byte)(C.removeSourceFunc)
-)
-
-// IdleAdd adds an idle source to the default main event loop context with the
-// DefaultIdle priority. If f is not a function with no parameter, then IdleAdd
-// will panic.
+// IdleAdd adds an idle source to the default main event loop context, which may
+// be changed using SetDefaultContext, with the DefaultIdle priority. f is
+// called with the given args, so it must take exactly as many parameters,
+// otherwise IdleAdd will panic. It is safe to call from any goroutine.
 //
 // After running once, the source func will be removed from the main event loop,
-// unless f returns a single bool true.
-func IdleAdd(f interface{}) SourceHandle {
-	return idleAdd(PRIORITY_DEFAULT_IDLE, f)
+// unless f returns a single bool true. f is kept alive until the source is
+// removed.
+func IdleAdd(f interface{}, args ...interface{}) SourceHandle {
+	return idleAdd(PRIORITY_DEFAULT_IDLE, f, args)
 }
 
 // IdleAddPriority adds an idle source to the default main event loop context
 // with the given priority. Its behavior is the same as IdleAdd.
 func IdleAddPriority(priority Priority, f interface{}) SourceHandle {
-	return idleAdd(priority, f)
+	return idleAdd(priority, f, nil)
 }
 
-func idleAdd(priority Priority, f interface{}) SourceHandle {
+func idleAdd(priority Priority, f interface{}, args []interface{}) SourceHandle {
+	if len(args) > 0 {
+		f = bindSourceArgs(f, args)
+	}
+
 	fs := closure.NewIdleFuncStack(f, 2)
 	id := C.gpointer(callback.Assign(fs))
-	h := C.g_idle_add_full(C.gint(priority), _sourceFunc, id, _removeSourceFunc)
 
-	return SourceHandle(h)
+	context := sourceContext()
+	defer C.g_main_context_unref(context)
+
+	registerSource(uintptr(id), context)
+	h := SourceHandle(C._g_source_attach_func(C.g_idle_source_new(), C.gint(priority), context, id))
+	registerSourceHandle(uintptr(id), h)
+
+	return h
 }
 
-// TimeoutAdd adds an timeout source to the default main event loop context.
-// Timeout is in milliseconds. If f is not a function with no parameter, then it
-// will panic.
+// TimeoutAdd adds an timeout source to the default main event loop context,
+// which may be changed using SetDefaultContext. Timeout is in milliseconds. f
+// is called with the given args the same way as by IdleAdd, and it will panic
+// if f can't take them.
 //
 // After running once, the source func will be removed from the main event loop,
 // unless f returns a single bool true.
-func TimeoutAdd(milliseconds uint, f interface{}) SourceHandle {
-	return timeoutAdd(milliseconds, false, PRIORITY_DEFAULT, f)
+func TimeoutAdd(milliseconds uint, f interface{}, args ...interface{}) SourceHandle {
+	return timeoutAdd(milliseconds, false, PRIORITY_DEFAULT, f, args)
 }
 
 // TimeoutAddPriority is similar to TimeoutAdd with the given priority. Refer to
 // TimeoutAdd for more information.
 func TimeoutAddPriority(milliseconds uint, priority Priority, f interface{}) SourceHandle {
-	return timeoutAdd(milliseconds, false, priority, f)
+	return timeoutAdd(milliseconds, false, priority, f, nil)
 }
 
 // TimeoutSecondsAdd is similar to TimeoutAdd, except with seconds granularity.
 func TimeoutSecondsAdd(seconds uint, f interface{}) SourceHandle {
-	return timeoutAdd(seconds, true, PRIORITY_DEFAULT, f)
+	return timeoutAdd(seconds, true, PRIORITY_DEFAULT, f, nil)
 }
 
 // TimeoutSecondsAddPriority adds a timeout source with the given priority.
 // Refer to TimeoutSecondsAdd for more information.
 func TimeoutSecondsAddPriority(seconds uint, priority Priority, f interface{}) SourceHandle {
-	return timeoutAdd(seconds, true, priority, f)
+	return timeoutAdd(seconds, true, priority, f, nil)
 }
 
-func timeoutAdd(time uint, sec bool, priority Priority, f interface{}) SourceHandle {
+func timeoutAdd(time uint, sec bool, priority Priority, f interface{}, args []interface{}) SourceHandle {
+	if len(args) > 0 {
+		f = bindSourceArgs(f, args)
+	}
+
 	fs := closure.NewIdleFuncStack(f, 2)
 	id := C.gpointer(callback.Assign(fs))
 
-	var h C.guint
+	context := sourceContext()
+	defer C.g_main_context_unref(context)
+
+	var source *C.GSource
 	if sec {
-		h = C.g_timeout_add_seconds_full(C.gint(priority), C.guint(time), _sourceFunc, id, _removeSourceFunc)
+		source = C.g_timeout_source_new_seconds(C.guint(time))
 	} else {
-		h = C.g_timeout_add_full(C.gint(priority), C.guint(time), _sourceFunc, id, _removeSourceFunc)
+		source = C.g_timeout_source_new(C.guint(time))
+	}
+
+	registerSource(uintptr(id), context)
+	h := SourceHandle(C._g_source_attach_func(source, C.gint(priority), context, id))
+	registerSourceHandle(uintptr(id), h)
+
+	return h
+}
+
+// bindSourceArgs returns a function without parameters that calls f with args.
+// The returned function has the same results as f.
+func bindSourceArgs(f interface{}, args []interface{}) interface{} {
+	fs := closure.NewFuncStack(f, 3)
+	fsType := fs.Func.Type()
+
+	if fsType.IsVariadic() {
+		fs.Panicf("variadic source func cannot receive args")
+	}
+	if fsType.NumIn() != len(args) {
+		fs.Panicf("source func takes %d parameters, got %d args", fsType.NumIn(), len(args))
 	}
 
-	return SourceHandle(h)
+	values := make([]reflect.Value, len(args))
+	for i, arg := range args {
+		argType := fsType.In(i)
+
+		if arg == nil {
+			values[i] = reflect.Zero(argType)
+			continue
+		}
+
+		values[i] = reflect.ValueOf(arg)
+		switch {
+		case values[i].Type().AssignableTo(argType):
+			// ok
+		case values[i].Type().ConvertibleTo(argType):
+			values[i] = values[i].Convert(argType)
+		default:
+			fs.Panicf("arg %d of type %s not convertible to %s", i, values[i].Type(), argType)
+		}
+	}
+
+	out := make([]reflect.Type, fsType.NumOut())
+	for i := range out {
+		out[i] = fsType.Out(i)
+	}
+
+	bound := reflect.MakeFunc(reflect.FuncOf(nil, out, false), func([]reflect.Value) []reflect.Value {
+		return fs.Func.Call(values)
+	})
+
+	return bound.Interface()
 }
 
 // Destroy is a wrapper around g_source_destroy()
@@ -435,6 +866,32 @@ func SourceRemove(src SourceHandle) bool {
 	return gobool(C.g_source_remove(C.guint(src)))
 }
 
+// Remove removes the source added by IdleAdd, TimeoutAdd or their variants,
+// which releases its func. Unlike SourceRemove, removing a source that has
+// already been removed, including one that removed itself after running, only
+// returns false instead of making GLib print a critical warning. False is also
+// returned for sources not added by this package; use SourceRemove for those.
+func (h SourceHandle) Remove() bool {
+	context := sourceHandleContext(h)
+	if context == nil {
+		return false
+	}
+
+	source := C.g_main_context_find_source_by_id(context, C.guint(h))
+	if source == nil {
+		return false
+	}
+
+	C.g_source_destroy(source)
+	return true
+}
+
+// RemoveSource calls h.Remove. It's meant to be passed as a function value,
+// such as to defer it.
+func RemoveSource(h SourceHandle) bool {
+	return h.Remove()
+}
+
 /*
  * Miscellaneous Utility Functions
  */
@@ -512,6 +969,24 @@ func SpacedPrimesClosest(num uint) uint {
 	return uint(C.g_spaced_primes_closest(C.guint(num)))
 }
 
+// RuntimeVersion returns the version of the GLib library linked at runtime,
+// given by glib_major_version, glib_minor_version and glib_micro_version.
+func RuntimeVersion() (major, minor, micro int) {
+	return int(C.glib_major_version), int(C.glib_minor_version), int(C.glib_micro_version)
+}
+
+// CheckVersion is a wrapper around glib_check_version(). It returns an error
+// describing the mismatch if the GLib library linked at runtime is not
+// compatible with the given version, which is the case if it's older or of
+// another major version.
+func CheckVersion(major, minor, micro int) error {
+	c := C.glib_check_version(C.guint(major), C.guint(minor), C.guint(micro))
+	if c == nil {
+		return nil
+	}
+	return errors.New(C.GoString((*C.char)(c)))
+}
+
 /*
  * GObject
  */
@@ -536,20 +1011,30 @@ type Object struct {
 //
 // To be clear, this should mostly be used when Gtk says "transfer none". Refer
 // to AssumeOwnership for more details.
+//
+// Objects wrapping the same pointer share the same native reference, so
+// wrapping an object that Go already references does not take another one.
+// Neither Take nor AssumeOwnership sink floating references: the floating
+// reference is owned by whoever sinks it, and Go's reference is always a full
+// one. Only ObjectNew and ObjectNewWithProperties sink the floating reference
+// of the objects that they construct, since it's theirs.
 func Take(ptr unsafe.Pointer) *Object {
-	obj := newObject(ptr)
+	obj, isNew := newObject(ptr)
 	if obj == nil {
 		return nil
 	}
 
-	obj.addToggleRef()
+	if isNew {
+		obj.addToggleRef()
+	}
 
 	return obj
 }
 
 // AssumeOwnership is similar to Take, except the function does not take a
-// reference. This is usually used for newly constructed objects that for some
-// reason does not have an initial floating reference.
+// reference. This is usually used for newly constructed objects. The given
+// reference must be a full one; a floating reference must be sunk using RefSink
+// beforehand, as Take describes.
 //
 // To be clear, this should often be used when Gtk says "transfer full", as it
 // means the ownership is transferred to us (Go), so we can assume that much.
@@ -557,17 +1042,99 @@ func Take(ptr unsafe.Pointer) *Object {
 // we're now referencing an object that might possibly be kept by C, so we
 // should take our own.
 func AssumeOwnership(ptr unsafe.Pointer) *Object {
-	obj := newObject(ptr)
+	obj, isNew := newObject(ptr)
 	if obj == nil {
 		return nil
 	}
 
-	obj.addToggleRef()
+	if isNew {
+		obj.addToggleRef()
+	}
 	obj.Unref()
 
 	return obj
 }
 
+// ObjectNew is a wrapper around g_object_new(). It creates a new instance of
+// the given type with no construct properties set.
+func ObjectNew(t Type) *Object {
+	c := C._g_object_new(C.GType(t))
+	return assumeNewObject(c)
+}
+
+// assumeNewObject sinks the reference of an object that was just constructed,
+// which is floating for a GInitiallyUnowned, and assumes ownership of it.
+// Sinking a floating reference doesn't take another one, so Go ends up with
+// the only reference either way.
+func assumeNewObject(c C.gpointer) *Object {
+	if c != nil && gobool(C.g_object_is_floating(c)) {
+		C.g_object_ref_sink(c)
+	}
+	return AssumeOwnership(unsafe.Pointer(c))
+}
+
+// ObjectNewWithProperties is a wrapper around g_object_new_with_properties().
+// It creates a new instance of the given type with the given properties set
+// during construction, which is the only way to set construct-only properties.
+// Properties declared by interfaces that the type implements are looked up on
+// its class like the type's own, so their construct properties can be set too.
+// The values are converted like in SetProperty.
+func ObjectNewWithProperties(t Type, properties map[string]interface{}) (*Object, error) {
+	if !t.IsA(TYPE_OBJECT) {
+		return nil, fmt.Errorf("%s is not a GObject type", t.Name())
+	}
+
+	// Interface properties are only known once the class and the interfaces
+	// that it implements are initialized.
+	class := C.g_type_class_ref(C.GType(t))
+	defer C.g_type_class_unref(class)
+
+	names := make([]string, 0, len(properties))
+	for name := range properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	cnames := make([]*C.char, len(names))
+	cvalues := make([]*C.GValue, len(names))
+	values := make([]*Value, len(names))
+
+	for i, name := range names {
+		cnames[i] = C.CString(name)
+		defer C.free(unsafe.Pointer(cnames[i]))
+
+		pspec := C.g_object_class_find_property((*C.GObjectClass)(class), (*C.gchar)(cnames[i]))
+		if pspec == nil {
+			return nil, fmt.Errorf("%s has no property %q", t.Name(), name)
+		}
+
+		value := properties[name]
+		if obj, ok := value.(Object); ok {
+			value = &obj
+		}
+
+		v, err := signalParamValue(value, Type(pspec.value_type))
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for property %q: %v", name, err)
+		}
+
+		values[i] = v
+		cvalues[i] = v.native()
+	}
+
+	var pnames **C.char
+	var pvalues **C.GValue
+	if len(names) > 0 {
+		pnames = &cnames[0]
+		pvalues = &cvalues[0]
+	}
+
+	c := C._g_object_new_with_properties(C.GType(t), C.guint(len(names)), pnames, pvalues)
+	runtime.KeepAlive(values)
+
+	return assumeNewObject(c), nil
+}
+
 //export goToggleNotify
 func goToggleNotify(_ C.gpointer, obj *C.GObject, isLastInt C.gboolean) {
 	isLast := isLastInt != 0
@@ -585,23 +1152,79 @@ func goToggleNotify(_ C.gpointer, obj *C.GObject, isLastInt C.gboolean) {
 
 // objectNative wraps around a native C object. It exists to work around
 // runtime.SetFinalizer's cyclic restriction.
+//
+// There is only one objectNative per GObject at a time, which holds the only
+// toggle reference that Go has on it. Its address is stored as qdata on the
+// GObject, so that wrapping the same pointer again reuses it.
 type objectNative struct {
 	GObject *C.GObject
+	// reused is set every time the objectNative is found through the qdata.
+	// It's guarded by objectNativesMu.
+	reused bool
 }
 
-// newObject creates a new Object from a GObject pointer with the finalizer set.
-func newObject(ptr unsafe.Pointer) *Object {
+// objectNativesMu guards the objectNative qdata of all GObjects.
+var objectNativesMu sync.Mutex
+
+// newObject creates a new Object from a GObject pointer. If the pointer is
+// already wrapped, then the existing objectNative is reused, otherwise a new
+// one is created with the finalizer set, and isNew is true. The caller must
+// add the toggle reference of new objectNatives.
+func newObject(ptr unsafe.Pointer) (obj *Object, isNew bool) {
 	if ptr == nil {
-		return nil
+		return nil, false
+	}
+
+	objectNativesMu.Lock()
+
+	native := loadObjectNative((*C.GObject)(ptr))
+	if native != nil {
+		native.reused = true
+	} else {
+		native = &objectNative{GObject: (*C.GObject)(ptr)}
+		native.attachFinalizer()
+		C._g_object_set_native(native.GObject, C.uintptr_t(uintptr(unsafe.Pointer(native))))
+		isNew = true
+
+		debugTrackObject(native)
 	}
 
-	native := &objectNative{GObject: (*C.GObject)(ptr)}
-	native.attachFinalizer()
+	objectNativesMu.Unlock()
 
-	return &Object{
+	obj = &Object{
 		objectNative: native,
 		box:          intern.ObjectBox(ptr),
 	}
+
+	return obj, isNew
+}
+
+// loadObjectNative returns the objectNative stored on the given GObject, or nil
+// if there is none. objectNativesMu must be held.
+//
+//go:nocheckptr
+func loadObjectNative(gobject *C.GObject) *objectNative {
+	p := uintptr(C._g_object_get_native(gobject))
+	if p == 0 {
+		return nil
+	}
+	// The qdata is cleared before the objectNative is freed, so the pointer
+	// is still valid here.
+	return (*objectNative)(unsafe.Pointer(p))
+}
+
+//export goObjectNativeDestroy
+func goObjectNativeDestroy(data C.uintptr_t) {
+	// The GObject is being finalized while still being wrapped, which only
+	// happens if it was unreferenced more than it should have been. Drop the
+	// pointer so that the finalizer doesn't touch the freed object.
+	objectNativesMu.Lock()
+	defer objectNativesMu.Unlock()
+
+	native := (*objectNative)(unsafe.Pointer(uintptr(data)))
+	native.GObject = nil
+
+	debugObjectDestroyed(native)
 }
 
 func (native *objectNative) addToggleRef() {
@@ -619,12 +1242,36 @@ func (native *objectNative) attachFinalizer() {
 func finalizeObjectNative(native *objectNative) {
 	log.Println("finalizing native", unsafe.Pointer(native.GObject))
 
+	objectNativesMu.Lock()
+
+	if native.GObject == nil {
+		objectNativesMu.Unlock()
+		debugUntrackObject(native)
+		return
+	}
+
+	if native.reused {
+		// The objectNative may have been found through the qdata after the
+		// collector deemed it unreachable, so it could be in use again.
+		// Delegate finalizing to the next cycle.
+		native.reused = false
+		native.attachFinalizer()
+		objectNativesMu.Unlock()
+		return
+	}
+
 	if !intern.ShouldFree(unsafe.Pointer(native.GObject)) {
 		// Delegate finalizing to the next cycle.
 		native.attachFinalizer()
+		objectNativesMu.Unlock()
 		return
 	}
 
+	C._g_object_steal_native(native.GObject)
+	objectNativesMu.Unlock()
+
+	debugUntrackObject(native)
+
 	native.removeToggleRef()
 }
 
@@ -699,128 +1346,1061 @@ func (v *Object) Unref() {
 	C.g_object_unref(C.gpointer(v.GObject))
 }
 
-// RefSink is a wrapper around g_object_ref_sink().
-func (v *Object) RefSink() {
-	C.g_object_ref_sink(C.gpointer(v.GObject))
-}
+// RefSink is a wrapper around g_object_ref_sink().
+func (v *Object) RefSink() {
+	C.g_object_ref_sink(C.gpointer(v.GObject))
+}
+
+// IsFloating is a wrapper around g_object_is_floating().
+func (v *Object) IsFloating() bool {
+	c := C.g_object_is_floating(C.gpointer(v.GObject))
+	return gobool(c)
+}
+
+// ForceFloating is a wrapper around g_object_force_floating().
+func (v *Object) ForceFloating() {
+	C.g_object_force_floating(v.GObject)
+}
+
+// FreezeNotify is a wrapper around g_object_freeze_notify().
+func (v *Object) FreezeNotify() {
+	C.g_object_freeze_notify(v.native())
+}
+
+// ThawNotify is a wrapper around g_object_thaw_notify().
+func (v *Object) ThawNotify() {
+	C.g_object_thaw_notify(v.native())
+}
+
+// WithFrozenNotify calls f with the notify signals of v frozen, so that
+// properties changed by f each notify once after f returns. The notify signals
+// are thawed even if f panics.
+func (v *Object) WithFrozenNotify(f func()) {
+	v.FreezeNotify()
+	defer v.ThawNotify()
+
+	f()
+}
+
+// CountNotifies connects to the "notify" signal of the given property, calls
+// during, then disconnects and returns the number of notifications emitted
+// while during was running. It's mostly useful for tests asserting property
+// changes.
+func (v *Object) CountNotifies(prop string, during func()) int {
+	var count int
+
+	handle := v.Connect("notify::"+prop, func() { count++ })
+	defer v.HandlerDisconnect(handle)
+
+	during()
+	return count
+}
+
+// WaitForFinalization waits until obj is finalized, returning false if it
+// wasn't within the given timeout. Meanwhile, the garbage collector is run and
+// the default main context is iterated repeatedly, so that both Go and C get
+// the chance to let go of the object. It is meant for leak tests.
+//
+// The caller must not use obj afterwards, and there must be no other Go
+// references to it, otherwise it will never be finalized.
+func WaitForFinalization(obj *Object, timeout time.Duration) bool {
+	finalized := make(chan struct{})
+
+	gobject := obj.native()
+	id := C.gpointer(callback.Assign(func() { close(finalized) }))
+	C.g_object_weak_ref(gobject, (*[0]byte)(C.goWeakNotify), id)
+
+	// Drop our own reference to the wrapper, so that it can be collected.
+	obj = nil
+
+	ctx := MainContextDefault()
+	deadline := time.Now().Add(timeout)
+
+	for {
+		runtime.GC()
+		for ctx.Pending() {
+			ctx.Iteration(false)
+		}
+
+		select {
+		case <-finalized:
+			return true
+		case <-time.After(10 * time.Millisecond):
+		}
+
+		if time.Now().After(deadline) {
+			break
+		}
+	}
+
+	select {
+	case <-finalized:
+		return true
+	default:
+		C.g_object_weak_unref(gobject, (*[0]byte)(C.goWeakNotify), id)
+		callback.Delete(uintptr(id))
+		return false
+	}
+}
+
+//export goWeakNotify
+func goWeakNotify(data C.gpointer, _ *C.GObject) {
+	if f, ok := callback.GetAndDelete(uintptr(data)).(func()); ok {
+		f()
+	}
+}
+
+// SetData is a wrapper around g_object_set_qdata_full(). It attaches data to v
+// under the given key, releasing the data previously attached under the same
+// key, if any. Passing nil data removes it. The data is released once v is
+// finalized, so it must not reference v, or v will never be finalized. The keys
+// don't clash with those used by g_object_set_data().
+func (v *Object) SetData(key string, data interface{}) {
+	setGoQdata(v.native(), goDataQuark(key), data)
+}
+
+// GetData is a wrapper around g_object_get_qdata(). It returns the data
+// attached to v under the given key using SetData, or nil if there is none.
+func (v *Object) GetData(key string) interface{} {
+	return getGoQdata(v.native(), goDataQuark(key))
+}
+
+// goDataQuark returns the quark of the given SetData key.
+func goDataQuark(key string) C.GQuark {
+	return C.GQuark(QuarkFromString("go-glib-data-" + key))
+}
+
+// setGoQdata attaches the Go value data to obj under quark, or removes the
+// value if data is nil. The previous value is released.
+func setGoQdata(obj *C.GObject, quark C.GQuark, data interface{}) {
+	var p C.gpointer
+	if data != nil {
+		// The first callback ID is 0, which would be taken for NULL.
+		p = C.gpointer(callback.Assign(data) + 1)
+	}
+	C._g_object_set_go_qdata(obj, quark, p)
+}
+
+// getGoQdata returns the Go value attached to obj under quark using
+// setGoQdata, or nil if there is none.
+func getGoQdata(obj *C.GObject, quark C.GQuark) interface{} {
+	p := uintptr(C.g_object_get_qdata(obj, quark))
+	if p == 0 {
+		return nil
+	}
+	return callback.Get(p - 1)
+}
+
+//export goObjectDataDestroy
+func goObjectDataDestroy(data C.gpointer) {
+	callback.Delete(uintptr(data) - 1)
+}
+
+// StopEmission is a wrapper around g_signal_stop_emission_by_name().
+func (v *Object) StopEmission(s string) {
+	cstr := C.CString(s)
+	defer C.free(unsafe.Pointer(cstr))
+	C.g_signal_stop_emission_by_name((C.gpointer)(v.GObject),
+		(*C.gchar)(cstr))
+}
+
+// Set calls SetProperty.
+func (v *Object) Set(name string, value interface{}) error {
+	return v.SetProperty(name, value)
+}
+
+// GetPropertyType returns the Type of a property of the underlying GObject.
+// If the property is missing it will return TYPE_INVALID and an error.
+func (v *Object) GetPropertyType(name string) (Type, error) {
+	cstr := C.CString(name)
+	defer C.free(unsafe.Pointer(cstr))
+
+	paramSpec := C.g_object_class_find_property(C._g_object_get_class(v.native()), (*C.gchar)(cstr))
+	if paramSpec == nil {
+		return TYPE_INVALID, errors.New("couldn't find Property")
+	}
+	return Type(paramSpec.value_type), nil
+}
+
+// GetProperty is a wrapper around g_object_get_property().
+func (v *Object) GetProperty(name string) (interface{}, error) {
+	cstr := C.CString(name)
+	defer C.free(unsafe.Pointer(cstr))
+
+	t, err := v.GetPropertyType(name)
+	if err != nil {
+		return nil, err
+	}
+
+	p, err := ValueInit(t)
+	if err != nil {
+		return nil, errors.New("unable to allocate value")
+	}
+	C.g_object_get_property(v.GObject, (*C.gchar)(cstr), p.native())
+	return p.GoValue()
+}
+
+// GetPropertyDefault returns the default value of the property with the given
+// name, as given by g_param_value_set_default().
+func (v *Object) GetPropertyDefault(name string) (interface{}, error) {
+	pspec := v.findProperty(name)
+	if pspec == nil {
+		return nil, errors.New("couldn't find Property")
+	}
+
+	p, err := ValueInit(Type(pspec.value_type))
+	if err != nil {
+		return nil, errors.New("unable to allocate value")
+	}
+	C.g_param_value_set_default(pspec, p.native())
+	return p.GoValue()
+}
+
+// SetProperty is a wrapper around g_object_set_property().
+func (v *Object) SetProperty(name string, value interface{}) error {
+	_, p, err := v.propertyValue(name, value)
+	if err != nil {
+		return err
+	}
+
+	cstr := C.CString(name)
+	defer C.free(unsafe.Pointer(cstr))
+
+	C.g_object_set_property(v.GObject, (*C.gchar)(cstr), p.native())
+	return nil
+}
+
+// propertyValue finds the property with the given name and converts value to
+// a Value of the property's type, the same way signal parameters are.
+func (v *Object) propertyValue(name string, value interface{}) (*C.GParamSpec, *Value, error) {
+	pspec := v.findProperty(name)
+	if pspec == nil {
+		return nil, nil, errors.New("couldn't find Property")
+	}
+
+	if obj, ok := value.(Object); ok {
+		value = &obj
+	}
+
+	p, err := signalParamValue(value, Type(pspec.value_type))
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid value for property %q: %v", name, err)
+	}
+
+	return pspec, p, nil
+}
+
+// SetPropertyFromString parses value according to the type of the property
+// with the given name and sets the property to it. Booleans, integers and
+// floating-point numbers are parsed using the strconv package, enums from the
+// nick or name of their values, and flags from nicks or names separated by
+// "|". Strings are set verbatim. An error is returned if the value cannot be
+// parsed or if the property has an unsupported type.
+func (v *Object) SetPropertyFromString(name, value string) error {
+	pspec := v.findProperty(name)
+	if pspec == nil {
+		return errors.New("couldn't find Property")
+	}
+
+	t := Type(pspec.value_type)
+
+	p, err := ValueInit(t)
+	if err != nil {
+		return errors.New("unable to allocate value")
+	}
+
+	if err := p.setFromString(t, value); err != nil {
+		return fmt.Errorf("invalid value for property %q: %v", name, err)
+	}
+
+	cstr := C.CString(name)
+	defer C.free(unsafe.Pointer(cstr))
+
+	C.g_object_set_property(v.GObject, (*C.gchar)(cstr), p.native())
+	return nil
+}
+
+// setFromString parses s into v, which must be initialized to t.
+func (v *Value) setFromString(t Type, s string) error {
+	switch fundamental := Type(C._g_value_fundamental(C.GType(t))); fundamental {
+	case TYPE_BOOLEAN:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+
+	case TYPE_CHAR, TYPE_INT, TYPE_LONG, TYPE_INT64:
+		n, err := strconv.ParseInt(s, 10, integerBits(fundamental))
+		if err != nil {
+			return err
+		}
+
+		switch fundamental {
+		case TYPE_CHAR:
+			v.SetSChar(int8(n))
+		case TYPE_INT:
+			v.SetInt(int(n))
+		case TYPE_LONG:
+			C.g_value_set_long(v.native(), C.glong(n))
+		case TYPE_INT64:
+			v.SetInt64(n)
+		}
+
+	case TYPE_UCHAR, TYPE_UINT, TYPE_ULONG, TYPE_UINT64:
+		n, err := strconv.ParseUint(s, 10, integerBits(fundamental))
+		if err != nil {
+			return err
+		}
+
+		switch fundamental {
+		case TYPE_UCHAR:
+			v.SetUChar(uint8(n))
+		case TYPE_UINT:
+			v.SetUInt(uint(n))
+		case TYPE_ULONG:
+			C.g_value_set_ulong(v.native(), C.gulong(n))
+		case TYPE_UINT64:
+			v.SetUInt64(n)
+		}
+
+	case TYPE_FLOAT:
+		f, err := strconv.ParseFloat(s, 32)
+		if err != nil {
+			return err
+		}
+		v.SetFloat(float32(f))
+
+	case TYPE_DOUBLE:
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return err
+		}
+		v.SetDouble(f)
+
+	case TYPE_STRING:
+		v.SetString(s)
+
+	case TYPE_ENUM:
+		cstr := (*C.gchar)(C.CString(s))
+		defer C.free(unsafe.Pointer(cstr))
+
+		var n C.gint
+		if !gobool(C._g_enum_value_by_nick(C.GType(t), cstr, &n)) {
+			return fmt.Errorf("no value %q in enum %s", s, t.Name())
+		}
+		C.g_value_set_enum(v.native(), n)
+
+	case TYPE_FLAGS:
+		var flags C.guint
+
+		for _, nick := range strings.Split(s, "|") {
+			nick = strings.TrimSpace(nick)
+			if nick == "" {
+				continue
+			}
+
+			cstr := (*C.gchar)(C.CString(nick))
+			var n C.guint
+			ok := gobool(C._g_flags_value_by_nick(C.GType(t), cstr, &n))
+			C.free(unsafe.Pointer(cstr))
+
+			if !ok {
+				return fmt.Errorf("no value %q in flags %s", nick, t.Name())
+			}
+			flags |= n
+		}
+
+		C.g_value_set_flags(v.native(), flags)
+
+	default:
+		return fmt.Errorf("unsupported type %s", t.Name())
+	}
+
+	return nil
+}
+
+// integerBits returns the size in bits of the given fundamental integer type.
+func integerBits(fundamental Type) int {
+	switch fundamental {
+	case TYPE_CHAR, TYPE_UCHAR:
+		return 8
+	case TYPE_INT, TYPE_UINT:
+		return int(C.sizeof_gint) * 8
+	case TYPE_LONG, TYPE_ULONG:
+		return int(C.sizeof_glong) * 8
+	default:
+		return 64
+	}
+}
+
+// SetPropertyIfChanged is similar to SetProperty, except the property is only
+// set if value differs from its current value according to
+// g_param_values_cmp(). This avoids emitting a notify signal when nothing has
+// changed. value is converted to the type of the property the same way as by
+// SetProperty before being compared.
+func (v *Object) SetPropertyIfChanged(name string, value interface{}) (changed bool, err error) {
+	pspec, newValue, err := v.propertyValue(name, value)
+	if err != nil {
+		return false, err
+	}
+
+	oldValue, err := ValueInit(Type(pspec.value_type))
+	if err != nil {
+		return false, errors.New("unable to allocate value")
+	}
+
+	cstr := C.CString(name)
+	defer C.free(unsafe.Pointer(cstr))
+
+	C.g_object_get_property(v.GObject, (*C.gchar)(cstr), oldValue.native())
+
+	if C.g_param_values_cmp(pspec, newValue.native(), oldValue.native()) == 0 {
+		return false, nil
+	}
+
+	C.g_object_set_property(v.GObject, (*C.gchar)(cstr), newValue.native())
+	return true, nil
+}
+
+// findProperty is a wrapper around g_object_class_find_property() for the class
+// of v. Nil is returned if v has no such property.
+func (v *Object) findProperty(name string) *C.GParamSpec {
+	cstr := (*C.gchar)(C.CString(name))
+	defer C.free(unsafe.Pointer(cstr))
+
+	return C.g_object_class_find_property(C._g_object_get_class(v.native()), cstr)
+}
+
+// FindProperty is a wrapper around g_object_class_find_property() for the class
+// of v. Nil is returned if v has no such property.
+func (v *Object) FindProperty(name string) *ParamSpec {
+	return takeParamSpec(v.findProperty(name))
+}
+
+// CopyProperties copies the values of the given properties from src to dst. If
+// no names are given, then all properties of src that dst also has are copied,
+// except for those that either can't read from src or write to dst, or that are
+// construct-only. The notify signals of dst are emitted once all properties are
+// copied.
+func CopyProperties(src, dst *Object, names ...string) error {
+	var pspecs []*C.GParamSpec
+
+	if len(names) > 0 {
+		for _, name := range names {
+			pspec := src.findProperty(name)
+			if pspec == nil {
+				return fmt.Errorf("source has no property %q", name)
+			}
+			if ParamFlags(pspec.flags)&PARAM_READABLE == 0 {
+				return fmt.Errorf("property %q of source is not readable", name)
+			}
+			if !propertyCopyable(dst, name) {
+				return fmt.Errorf("property %q of destination is not writable", name)
+			}
+			pspecs = append(pspecs, pspec)
+		}
+	} else {
+		var n C.guint
+		list := C.g_object_class_list_properties(C._g_object_get_class(src.native()), &n)
+		defer C.g_free(C.gpointer(list))
+
+		for _, pspec := range paramSpecSlice(list, int(n)) {
+			flags := ParamFlags(pspec.flags)
+			if flags&PARAM_READABLE == 0 || flags&PARAM_CONSTRUCT_ONLY != 0 {
+				continue
+			}
+
+			name := C.GoString((*C.char)(C.g_param_spec_get_name(pspec)))
+			if propertyCopyable(dst, name) {
+				pspecs = append(pspecs, pspec)
+			}
+		}
+	}
+
+	dst.FreezeNotify()
+	defer dst.ThawNotify()
+
+	for _, pspec := range pspecs {
+		value, err := ValueInit(Type(pspec.value_type))
+		if err != nil {
+			return errors.New("unable to allocate value")
+		}
+
+		name := C.g_param_spec_get_name(pspec)
+		C.g_object_get_property(src.native(), name, value.native())
+		C.g_object_set_property(dst.native(), name, value.native())
+	}
+
+	return nil
+}
+
+// GetPropertiesAsVariant returns an "a{sv}" dictionary of the given properties
+// of v, or of all readable properties if none are given, converted using
+// g_dbus_gvalue_to_gvariant(). Properties of booleans, numbers, strings, enums
+// and flags are supported; enums are converted to "i" and flags to "u". When
+// reading all properties, the unsupported ones are skipped, otherwise nil is
+// returned if any of the given properties is missing, not readable or
+// unsupported.
+func (v *Object) GetPropertiesAsVariant(names ...string) *Variant {
+	var pspecs []*C.GParamSpec
+
+	if len(names) > 0 {
+		for _, name := range names {
+			pspec := v.findProperty(name)
+			if pspec == nil || ParamFlags(pspec.flags)&PARAM_READABLE == 0 {
+				return nil
+			}
+			if _, _, ok := propertyVariantType(Type(pspec.value_type)); !ok {
+				return nil
+			}
+			pspecs = append(pspecs, pspec)
+		}
+	} else {
+		var n C.guint
+		list := C.g_object_class_list_properties(C._g_object_get_class(v.native()), &n)
+		defer C.g_free(C.gpointer(list))
+
+		for _, pspec := range paramSpecSlice(list, int(n)) {
+			if ParamFlags(pspec.flags)&PARAM_READABLE == 0 {
+				continue
+			}
+			if _, _, ok := propertyVariantType(Type(pspec.value_type)); ok {
+				pspecs = append(pspecs, pspec)
+			}
+		}
+	}
+
+	entries := make([]*Variant, 0, len(pspecs))
+
+	for _, pspec := range pspecs {
+		value, err := ValueInit(Type(pspec.value_type))
+		if err != nil {
+			return nil
+		}
+
+		C.g_object_get_property(v.native(), C.g_param_spec_get_name(pspec), value.native())
+
+		variantType, valueType, _ := propertyVariantType(Type(pspec.value_type))
+
+		converted, err := ValueInit(valueType)
+		if err != nil {
+			return nil
+		}
+		if !gobool(C.g_value_transform(value.native(), converted.native())) {
+			return nil
+		}
+
+		variant := assumeVariant(C.g_dbus_gvalue_to_gvariant(converted.native(), variantType.native()))
+		if variant == nil {
+			return nil
+		}
+
+		name := C.GoString((*C.char)(C.g_param_spec_get_name(pspec)))
+		entries = append(entries, NewVariantDictEntry(VariantFromString(name), VariantFromVariant(variant)))
+	}
+
+	return NewVariantArray(VariantTypeNew("{sv}"), entries)
+}
+
+// ApplyVariantDict sets the properties of v to the values of the given "a{sv}"
+// dictionary, such as one returned by GetPropertiesAsVariant. The values are
+// converted using g_dbus_gvariant_to_gvalue(), then to the type of their
+// property if needed. Keys that aren't writable properties of v are skipped,
+// and a warning is logged using the standard logger for each of them.
+//
+// An error is returned if dict is not an "a{sv}" dictionary or if a value
+// cannot be converted, in which case the properties before it are still set.
+func (v *Object) ApplyVariantDict(dict *Variant) error {
+	if !dict.IsType(VARIANT_TYPE_VARDICT) {
+		return fmt.Errorf("expected variant of type a{sv}, got %s", dict.TypeString())
+	}
+
+	v.FreezeNotify()
+	defer v.ThawNotify()
+
+	for i := uint(0); i < dict.NChildren(); i++ {
+		key, boxed := dict.ChildValue(i).DictEntry()
+		name := key.GetString()
+
+		pspec := v.findProperty(name)
+		if pspec == nil {
+			log.Printf("glib: skipping unknown property %q of %s", name, v.TypeFromInstance().Name())
+			continue
+		}
+		flags := ParamFlags(pspec.flags)
+		if flags&PARAM_WRITABLE == 0 || flags&PARAM_CONSTRUCT_ONLY != 0 {
+			log.Printf("glib: skipping property %q of %s, which is not writable", name, v.TypeFromInstance().Name())
+			continue
+		}
+
+		value, err := ValueAlloc()
+		if err != nil {
+			return errors.New("unable to allocate value")
+		}
+		C.g_dbus_gvariant_to_gvalue(boxed.GetVariant().native(), value.native())
+
+		converted, err := propertyValue(value, Type(pspec.value_type))
+		if err != nil {
+			return fmt.Errorf("invalid value for property %q: %v", name, err)
+		}
+
+		C.g_object_set_property(v.native(), C.g_param_spec_get_name(pspec), converted.native())
+	}
+
+	return nil
+}
+
+// propertyValue converts value to a value of the property type t. Integers are
+// converted to enums and flags, which GLib can't transform to.
+func propertyValue(value *Value, t Type) (*Value, error) {
+	valueType := Type(C._g_value_type(value.native()))
+	if gobool(C.g_value_type_compatible(C.GType(valueType), C.GType(t))) {
+		return value, nil
+	}
+
+	converted, err := ValueInit(t)
+	if err != nil {
+		return nil, errors.New("unable to allocate value")
+	}
+
+	switch fundamental := Type(C._g_value_fundamental(C.GType(t))); {
+	case fundamental == TYPE_ENUM && valueType == TYPE_INT:
+		C.g_value_set_enum(converted.native(), C.g_value_get_int(value.native()))
+	case fundamental == TYPE_FLAGS && valueType == TYPE_UINT:
+		C.g_value_set_flags(converted.native(), C.g_value_get_uint(value.native()))
+	default:
+		if !gobool(C.g_value_transform(value.native(), converted.native())) {
+			return nil, fmt.Errorf("cannot convert %s to %s", valueType.Name(), t.Name())
+		}
+	}
+
+	return converted, nil
+}
+
+// propertyVariantType returns the variant type that properties of type t are
+// converted to by GetPropertiesAsVariant, along with the type that their values
+// must be transformed to first.
+func propertyVariantType(t Type) (variantType *VariantType, valueType Type, ok bool) {
+	fundamental := Type(C._g_value_fundamental(C.GType(t)))
+
+	switch fundamental {
+	case TYPE_BOOLEAN:
+		return VARIANT_TYPE_BOOLEAN, TYPE_BOOLEAN, true
+	case TYPE_UCHAR:
+		return VARIANT_TYPE_BYTE, TYPE_UCHAR, true
+	case TYPE_INT, TYPE_ENUM:
+		return VARIANT_TYPE_INT32, TYPE_INT, true
+	case TYPE_UINT, TYPE_FLAGS:
+		return VARIANT_TYPE_UINT32, TYPE_UINT, true
+	case TYPE_LONG, TYPE_INT64:
+		return VARIANT_TYPE_INT64, TYPE_INT64, true
+	case TYPE_ULONG, TYPE_UINT64:
+		return VARIANT_TYPE_UINT64, TYPE_UINT64, true
+	case TYPE_FLOAT, TYPE_DOUBLE:
+		return VARIANT_TYPE_DOUBLE, TYPE_DOUBLE, true
+	case TYPE_STRING:
+		return VARIANT_TYPE_STRING, TYPE_STRING, true
+	default:
+		return nil, TYPE_INVALID, false
+	}
+}
+
+// maxCycleSearch is the maximum number of objects that DetectCycle visits.
+const maxCycleSearch = 1000
+
+// DetectCycle searches the objects held by the readable object properties of
+// root, breadth-first, for a chain of properties that leads back to root. The
+// names of the properties along the shortest such chain are returned, each
+// being read from the object that the previous ones lead to, or nil if there is
+// none. The search gives up after visiting a limited number of objects.
+//
+// DetectCycle is meant for tests that check for reference cycles, which
+// prevent objects from ever being freed.
+func DetectCycle(root *Object) []string {
+	type node struct {
+		gobject *C.GObject
+		path    []string
+	}
+
+	// The values hold references to the objects being searched, keeping them
+	// alive until the search is done.
+	var values []*Value
+
+	visited := map[*C.GObject]bool{root.native(): true}
+	queue := []node{{root.native(), nil}}
+
+	for len(queue) > 0 && len(visited) <= maxCycleSearch {
+		n := queue[0]
+		queue = queue[1:]
+
+		var nProps C.guint
+		list := C.g_object_class_list_properties(C._g_object_get_class(n.gobject), &nProps)
+
+		for _, pspec := range paramSpecSlice(list, int(nProps)) {
+			if ParamFlags(pspec.flags)&PARAM_READABLE == 0 || !Type(pspec.value_type).IsA(TYPE_OBJECT) {
+				continue
+			}
+
+			value, err := ValueInit(Type(pspec.value_type))
+			if err != nil {
+				continue
+			}
+
+			name := C.g_param_spec_get_name(pspec)
+			C.g_object_get_property(n.gobject, name, value.native())
+
+			child := (*C.GObject)(C.g_value_get_object(value.native()))
+			if child == nil {
+				continue
+			}
+
+			path := append(n.path[:len(n.path):len(n.path)], C.GoString((*C.char)(name)))
+			if child == root.native() {
+				C.g_free(C.gpointer(list))
+				return path
+			}
+
+			if !visited[child] {
+				visited[child] = true
+				values = append(values, value)
+				queue = append(queue, node{child, path})
+			}
+		}
+
+		C.g_free(C.gpointer(list))
+	}
+
+	runtime.KeepAlive(values)
+	return nil
+}
+
+// propertyCopyable returns true if the property of the given name can be set
+// on v after construction.
+func propertyCopyable(v *Object, name string) bool {
+	pspec := v.findProperty(name)
+	if pspec == nil {
+		return false
+	}
+
+	flags := ParamFlags(pspec.flags)
+	return flags&PARAM_WRITABLE != 0 && flags&PARAM_CONSTRUCT_ONLY == 0
+}
+
+func paramSpecSlice(values **C.GParamSpec, nValues int) (slice []*C.GParamSpec) {
+	header := (*reflect.SliceHeader)((unsafe.Pointer(&slice)))
+	header.Cap = nValues
+	header.Len = nValues
+	header.Data = uintptr(unsafe.Pointer(values))
+	return
+}
+
+/*
+ * GObject Signals
+ */
+
+// Emit is a wrapper around g_signal_emitv() and emits the signal
+// specified by the string s to an Object. s may be a detailed signal, such as
+// "notify::prop". Arguments to callback functions connected to this signal
+// must be specified in args. They are converted to GValues the same way as
+// return values of callbacks are, then to the parameter types of the signal
+// if needed. Emit() returns an interface{} which must be type asserted as the
+// Go equivalent type to the return value for native C callback, or nil if the
+// signal has no return value. If the signal has an accumulator, such as one
+// stopping at the first handler returning a value, the accumulated value is
+// returned rather than the one of the last handler; handlers returning nil are
+// given to the accumulator as NULL.
+//
+// An error is returned if the signal doesn't exist, if the number of args
+// doesn't match the signal's parameters, or if an arg cannot be converted to
+// the type of its parameter.
+func (v *Object) Emit(s string, args ...interface{}) (interface{}, error) {
+	signalID, detail, params, err := v.signalValues(s, args)
+	if err != nil {
+		return nil, err
+	}
+
+	ret, err := v.EmitValues(signalID, detail, params)
+	if err != nil || ret == nil {
+		return nil, err
+	}
 
-// IsFloating is a wrapper around g_object_is_floating().
-func (v *Object) IsFloating() bool {
-	c := C.g_object_is_floating(C.gpointer(v.GObject))
-	return gobool(c)
+	return ret.GoValue()
 }
 
-// ForceFloating is a wrapper around g_object_force_floating().
-func (v *Object) ForceFloating() {
-	C.g_object_force_floating(v.GObject)
+// EmitAsync emits the signal s to an Object from the default main context, so
+// that it can be called from any goroutine without the handlers running on the
+// wrong thread. The signal is emitted from an idle source, after EmitAsync
+// returns, and its return value is discarded.
+//
+// args are converted to GValues the same way as by Emit before EmitAsync
+// returns, which copies them: strings and boxed types are copied, and a
+// reference is taken on Objects until the signal is emitted. Raw pointers, such
+// as unsafe.Pointer and uintptr args, are passed as-is, so whatever they point
+// to must be kept valid until the signal is emitted. Errors are logged, since
+// they can't be returned.
+func (v *Object) EmitAsync(s string, args ...interface{}) {
+	signalID, detail, params, err := v.signalValues(s, args)
+	if err != nil {
+		log.Printf("glib: cannot emit %q asynchronously: %v", s, err)
+		return
+	}
+
+	IdleAdd(func() {
+		if _, err := v.EmitValues(signalID, detail, params); err != nil {
+			log.Printf("glib: cannot emit %q asynchronously: %v", s, err)
+		}
+	})
 }
 
-// StopEmission is a wrapper around g_signal_stop_emission_by_name().
-func (v *Object) StopEmission(s string) {
+// signalValues parses the detailed signal s of v and converts args to the
+// Values of its parameters.
+func (v *Object) signalValues(s string, args []interface{}) (uint, Quark, []*Value, error) {
 	cstr := C.CString(s)
 	defer C.free(unsafe.Pointer(cstr))
-	C.g_signal_stop_emission_by_name((C.gpointer)(v.GObject),
-		(*C.gchar)(cstr))
-}
 
-// Set calls SetProperty.
-func (v *Object) Set(name string, value interface{}) error {
-	return v.SetProperty(name, value)
-}
+	var signalID C.guint
+	var detail C.GQuark
 
-// GetPropertyType returns the Type of a property of the underlying GObject.
-// If the property is missing it will return TYPE_INVALID and an error.
-func (v *Object) GetPropertyType(name string) (Type, error) {
-	cstr := C.CString(name)
-	defer C.free(unsafe.Pointer(cstr))
+	if !gobool(C.g_signal_parse_name((*C.gchar)(cstr), C._g_type_from_instance(C.gpointer(v.native())), &signalID, &detail, C.TRUE)) {
+		return 0, 0, nil, fmt.Errorf("unknown signal %q for type %s", s, v.TypeFromInstance().Name())
+	}
 
-	paramSpec := C.g_object_class_find_property(C._g_object_get_class(v.native()), (*C.gchar)(cstr))
-	if paramSpec == nil {
-		return TYPE_INVALID, errors.New("couldn't find Property")
+	var query C.GSignalQuery
+	C.g_signal_query(signalID, &query)
+
+	if int(query.n_params) != len(args) {
+		return 0, 0, nil, fmt.Errorf("signal %s takes %d parameters, got %d", s, query.n_params, len(args))
 	}
-	return Type(paramSpec.value_type), nil
+
+	params := make([]*Value, len(args))
+	for i := range args {
+		val, err := signalParamValue(args[i], Type(C._g_signal_query_param_type(&query, C.guint(i))))
+		if err != nil {
+			return 0, 0, nil, fmt.Errorf("Error converting arg %d to GValue: %s", i, err.Error())
+		}
+		params[i] = val
+	}
+
+	return uint(signalID), Quark(detail), params, nil
 }
 
-// GetProperty is a wrapper around g_object_get_property().
-func (v *Object) GetProperty(name string) (interface{}, error) {
-	cstr := C.CString(name)
-	defer C.free(unsafe.Pointer(cstr))
+// signalParamValue converts arg to a GValue of the given signal parameter
+// type.
+func signalParamValue(arg interface{}, paramType Type) (*Value, error) {
+	// Objects and ParamSpecs are stored as the exact type of the parameter,
+	// since GLib doesn't convert between their types.
+	switch arg := arg.(type) {
+	case nil:
+		// The zero value of the type, such as a NULL object or string.
+		return ValueInit(paramType)
 
-	t, err := v.GetPropertyType(name)
-	if err != nil {
-		return nil, err
+	case *Object:
+		if arg == nil {
+			return ValueInit(paramType)
+		}
+		if !arg.IsA(paramType) {
+			return nil, fmt.Errorf("%s is not a %s", arg.TypeFromInstance().Name(), paramType.Name())
+		}
+		val, err := ValueInit(paramType)
+		if err != nil {
+			return nil, err
+		}
+		val.SetInstance(uintptr(unsafe.Pointer(arg.GObject)))
+		return val, nil
+
+	case *ParamSpec:
+		if !gobool(C.g_type_is_a(C._g_param_spec_type(arg.native()), C.GType(paramType))) {
+			return nil, fmt.Errorf("ParamSpec is not a %s", paramType.Name())
+		}
+		val, err := ValueInit(paramType)
+		if err != nil {
+			return nil, err
+		}
+		C.g_value_set_param(val.native(), arg.native())
+		return val, nil
 	}
 
-	p, err := ValueInit(t)
+	// GValue would store int and uint as 32-bit integers, which would truncate
+	// the values of 64-bit and platform-width parameters, such as gsize ones.
+	if val, ok, err := wideIntegerValue(arg, paramType); ok {
+		return val, err
+	}
+
+	val, err := GValue(arg)
 	if err != nil {
-		return nil, errors.New("unable to allocate value")
+		return nil, err
 	}
-	C.g_object_get_property(v.GObject, (*C.gchar)(cstr), p.native())
-	return p.GoValue()
+
+	return propertyValue(val, paramType)
 }
 
-// SetProperty is a wrapper around g_object_set_property().
-func (v *Object) SetProperty(name string, value interface{}) error {
-	cstr := C.CString(name)
-	defer C.free(unsafe.Pointer(cstr))
+// wideIntegerValue converts a Go integer to a value of t if it's a long or
+// 64-bit integer type, which are the types of gssize and gsize. ok is false if
+// arg isn't an integer or t isn't one of these types. An error is returned if
+// the integer doesn't fit in t.
+func wideIntegerValue(arg interface{}, t Type) (val *Value, ok bool, err error) {
+	fundamental := Type(C._g_value_fundamental(C.GType(t)))
+	switch fundamental {
+	case TYPE_LONG, TYPE_ULONG, TYPE_INT64, TYPE_UINT64:
+	default:
+		return nil, false, nil
+	}
 
-	if _, ok := value.(Object); ok {
-		value = value.(Object).GObject
+	var (
+		i      int64
+		u      uint64
+		signed bool
+	)
+
+	rval := reflect.ValueOf(arg)
+	switch rval.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i = rval.Int()
+		u = uint64(i)
+		signed = true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		u = rval.Uint()
+		i = int64(u)
+	default:
+		return nil, false, nil
 	}
 
-	p, err := GValue(value)
+	val, err = ValueInit(t)
 	if err != nil {
-		return errors.New("Unable to perform type conversion")
+		return nil, true, errors.New("unable to allocate value")
 	}
-	C.g_object_set_property(v.GObject, (*C.gchar)(cstr), p.native())
-	return nil
+
+	overflow := fmt.Errorf("%v overflows %s", arg, t.Name())
+
+	switch fundamental {
+	case TYPE_LONG, TYPE_INT64:
+		if !signed && i < 0 {
+			return nil, true, overflow
+		}
+		if fundamental == TYPE_INT64 {
+			C.g_value_set_int64(val.native(), C.gint64(i))
+			break
+		}
+		if int64(C.glong(i)) != i {
+			return nil, true, overflow
+		}
+		C.g_value_set_long(val.native(), C.glong(i))
+
+	case TYPE_ULONG, TYPE_UINT64:
+		if signed && i < 0 {
+			return nil, true, overflow
+		}
+		if fundamental == TYPE_UINT64 {
+			C.g_value_set_uint64(val.native(), C.guint64(u))
+			break
+		}
+		if uint64(C.gulong(u)) != u {
+			return nil, true, overflow
+		}
+		C.g_value_set_ulong(val.native(), C.gulong(u))
+	}
+
+	return val, true, nil
 }
 
-/*
- * GObject Signals
- */
+// SignalLookup is a wrapper around g_signal_lookup(). It returns 0 if no signal
+// of the given name exists on t.
+func SignalLookup(name string, t Type) uint {
+	cstr := (*C.gchar)(C.CString(name))
+	defer C.free(unsafe.Pointer(cstr))
 
-// Emit is a wrapper around g_signal_emitv() and emits the signal
-// specified by the string s to an Object.  Arguments to callback
-// functions connected to this signal must be specified in args.  Emit()
-// returns an interface{} which must be type asserted as the Go
-// equivalent type to the return value for native C callback.
+	return uint(C.g_signal_lookup(cstr, C.GType(t)))
+}
+
+// EmitValues is a wrapper around g_signal_emitv(). Unlike Emit, the arguments
+// are given as already prepared Values, which must hold the types of the
+// signal's parameters, skipping the conversion of Go values. The Values are
+// only read, so they can be reused across emissions. The instance is prepended
+// to params by EmitValues.
 //
-// Note that this code is unsafe in that the types of values in args are
-// not checked against whether they are suitable for the callback.
-func (v *Object) Emit(s string, args ...interface{}) (interface{}, error) {
-	cstr := C.CString(s)
-	defer C.free(unsafe.Pointer(cstr))
+// The returned Value holds the return value of the signal, or is nil if the
+// signal doesn't return anything.
+func (v *Object) EmitValues(signalID uint, detail Quark, params []*Value) (*Value, error) {
+	var query C.GSignalQuery
+	C.g_signal_query(C.guint(signalID), &query)
+
+	if query.signal_id == 0 {
+		return nil, fmt.Errorf("invalid signal ID %d", signalID)
+	}
+	if !v.IsA(Type(query.itype)) {
+		return nil, fmt.Errorf("signal %s is not defined on %s",
+			C.GoString((*C.char)(query.signal_name)), v.TypeFromInstance().Name())
+	}
+	if int(query.n_params) != len(params) {
+		return nil, fmt.Errorf("signal %s takes %d parameters, got %d",
+			C.GoString((*C.char)(query.signal_name)), query.n_params, len(params))
+	}
 
-	// Create array of this instance and arguments
-	valv := C.alloc_gvalue_list(C.int(len(args)) + 1)
-	defer C.free(unsafe.Pointer(valv))
+	valv := C.alloc_gvalue_list(C.int(len(params)) + 1)
+	defer C.g_free(C.gpointer(valv))
 
-	// Add args and valv
-	val, err := GValue(v)
+	instance, err := GValue(v)
 	if err != nil {
 		return nil, errors.New("Error converting Object to GValue: " + err.Error())
 	}
-	C.val_list_insert(valv, C.int(0), val.native())
-	for i := range args {
-		val, err := GValue(args[i])
+	C.val_list_insert(valv, C.int(0), instance.native())
+	for i, param := range params {
+		C.val_list_insert(valv, C.int(i+1), param.native())
+	}
+
+	var ret *Value
+	var retNative *C.GValue
+
+	returnType := Type(C._g_signal_query_return_type(&query))
+	if returnType != TYPE_NONE {
+		ret, err = ValueInit(returnType)
 		if err != nil {
-			return nil, fmt.Errorf("Error converting arg %d to GValue: %s", i, err.Error())
+			return nil, errors.New("Error creating Value for return value")
 		}
-		C.val_list_insert(valv, C.int(i+1), val.native())
+		retNative = ret.native()
 	}
 
-	t := v.TypeFromInstance()
-	// TODO: use just the signal name
-	id := C.g_signal_lookup((*C.gchar)(cstr), C.GType(t))
+	C.g_signal_emitv(valv, C.guint(signalID), C.GQuark(detail), retNative)
 
-	ret, err := ValueAlloc()
-	if err != nil {
-		return nil, errors.New("Error creating Value for return value")
+	// The GValues were copied bitwise into valv, so the originals must stay
+	// around until the emission is done.
+	runtime.KeepAlive(instance)
+	runtime.KeepAlive(params)
+
+	return ret, nil
+}
+
+// ListSignals returns the names of all signals defined on the object's type
+// and its ancestors, starting from the object's type. It's a wrapper around
+// g_signal_list_ids() and g_signal_name().
+func (v *Object) ListSignals() []string {
+	var names []string
+
+	for t := v.TypeFromInstance(); t != TYPE_INVALID; t = t.Parent() {
+		var n C.guint
+		ids := C.g_signal_list_ids(C.GType(t), &n)
+		if ids == nil {
+			continue
+		}
+
+		for _, id := range guintSlice(ids, int(n)) {
+			names = append(names, C.GoString((*C.char)(C.g_signal_name(id))))
+		}
+
+		C.g_free(C.gpointer(ids))
 	}
-	C.g_signal_emitv(valv, id, C.GQuark(0), ret.native())
 
-	return ret.GoValue()
+	return names
+}
+
+// guintSlice converts a C array of guints to a Go slice.
+func guintSlice(values *C.guint, nValues int) (slice []C.guint) {
+	header := (*reflect.SliceHeader)((unsafe.Pointer(&slice)))
+	header.Cap = nValues
+	header.Len = nValues
+	header.Data = uintptr(unsafe.Pointer(values))
+	return
 }
 
 // HandlerBlock is a wrapper around g_signal_handler_block().
@@ -834,11 +2414,21 @@ func (v *Object) HandlerUnblock(handle SignalHandle) {
 }
 
 // HandlerDisconnect is a wrapper around g_signal_handler_disconnect().
+// Disconnecting a handler that isn't connected, such as one that has already
+// been disconnected, does nothing.
 func (v *Object) HandlerDisconnect(handle SignalHandle) {
+	if !v.HandlerIsConnected(handle) {
+		return
+	}
 	// Ensure that Gtk will not use the closure beforehand.
 	C.g_signal_handler_disconnect(C.gpointer(v.GObject), C.gulong(handle))
 }
 
+// HandlerIsConnected is a wrapper around g_signal_handler_is_connected().
+func (v *Object) HandlerIsConnected(handle SignalHandle) bool {
+	return gobool(C.g_signal_handler_is_connected(C.gpointer(v.GObject), C.gulong(handle)))
+}
+
 // Wrapper function for new objects with reference management.
 func wrapObject(ptr unsafe.Pointer) *Object {
 	return Take(ptr)
@@ -910,20 +2500,7 @@ func ValueAlloc() (*Value, error) {
 	}
 
 	v := &Value{c}
-
-	//An allocated GValue is not guaranteed to hold a value that can be unset
-	//We need to double check before unsetting, to prevent:
-	//`g_value_unset: assertion 'G_IS_VALUE (value)' failed`
-	runtime.SetFinalizer(v, func(f *Value) {
-
-		if !f.IsValue() {
-			C.g_free(C.gpointer(f.native()))
-			return
-		}
-
-		f.unset()
-	})
-
+	runtime.SetFinalizer(v, (*Value).free)
 	return v, nil
 }
 
@@ -938,11 +2515,17 @@ func ValueInit(t Type) (*Value, error) {
 	}
 
 	v := &Value{c}
-
-	runtime.SetFinalizer(v, (*Value).unset)
+	runtime.SetFinalizer(v, (*Value).free)
 	return v, nil
 }
 
+// NewValue converts a Go value to a new Value, choosing the type of the Value
+// the same way GValue does. The Value is unset and freed once it's garbage
+// collected.
+func NewValue(v interface{}) (*Value, error) {
+	return GValue(v)
+}
+
 // ValueFromNative returns a type-asserted pointer to the Value.
 func ValueFromNative(l unsafe.Pointer) *Value {
 	//TODO why it does not add finalizer to the value?
@@ -953,6 +2536,30 @@ func (v *Value) unset() {
 	C.g_value_unset(v.native())
 }
 
+// free unsets the GValue allocated by ValueAlloc or ValueInit and frees it.
+// An allocated GValue is not guaranteed to hold a value, so it's only unset if
+// it does, to prevent:
+// `g_value_unset: assertion 'G_IS_VALUE (value)' failed`
+func (v *Value) free() {
+	if v.IsValue() {
+		v.unset()
+	}
+	C.g_free(C.gpointer(v.native()))
+}
+
+// holds returns a non-nil error if v doesn't hold a value whose fundamental
+// type is t.
+func (v *Value) holds(t Type) error {
+	_, fundamental, err := v.Type()
+	if err != nil {
+		return err
+	}
+	if fundamental != t {
+		return fmt.Errorf("value of type %s does not hold a %s", v.TypeName(), t.Name())
+	}
+	return nil
+}
+
 // Unset is wrapper for g_value_unset
 func (v *Value) Unset() {
 	v.unset()
@@ -1294,6 +2901,76 @@ func marshalVariant(p uintptr) (interface{}, error) {
 	return newVariant((*C.GVariant)(c)), nil
 }
 
+var objectSliceType = reflect.TypeOf([]*Object(nil))
+
+// objectSlice converts the GPtrArray of GObjects held by v into a slice of
+// Objects, taking a reference on each. False is returned if v does not hold a
+// GPtrArray. The elements of the GPtrArray must all be GObjects or NULL.
+func (v *Value) objectSlice() ([]*Object, bool) {
+	if !v.IsValue() || !gobool(C._g_value_holds_ptr_array(v.native())) {
+		return nil, false
+	}
+
+	array := (*C.GPtrArray)(C.g_value_get_boxed(v.native()))
+	if array == nil {
+		return nil, true
+	}
+
+	objs := make([]*Object, array.len)
+	for i := range objs {
+		objs[i] = Take(unsafe.Pointer(C._g_ptr_array_index(array, C.guint(i))))
+	}
+
+	return objs, true
+}
+
+var paramSpecType = reflect.TypeOf((*ParamSpec)(nil))
+
+// paramSpec returns the GParamSpec held by v, taking a reference on it. False
+// is returned if v does not hold a GParamSpec.
+func (v *Value) paramSpec() (*ParamSpec, bool) {
+	_, fundamental, err := v.Type()
+	if err != nil || fundamental != TYPE_PARAM {
+		return nil, false
+	}
+
+	return takeParamSpec(C.g_value_get_param(v.native())), true
+}
+
+var valueType = reflect.TypeOf((*Value)(nil))
+
+// boxedValue returns the GValue boxed in v. False is returned if v does not
+// hold a boxed GValue. The returned Value is only valid as long as v is.
+func (v *Value) boxedValue() (*Value, bool) {
+	actual, _, err := v.Type()
+	if err != nil || actual != Type(C.G_TYPE_VALUE) {
+		return nil, false
+	}
+
+	c := (*C.GValue)(C.g_value_get_boxed(v.native()))
+	if c == nil {
+		return nil, false
+	}
+	return &Value{c}, true
+}
+
+// enumNick returns the nick of the enum value held by v. False is returned if
+// v does not hold an enum or if the enum value is unknown.
+func (v *Value) enumNick() (string, bool) {
+	_, fundamental, err := v.Type()
+	if err != nil || fundamental != TYPE_ENUM {
+		return "", false
+	}
+
+	nick := C._g_value_dup_enum_nick(v.native())
+	if nick == nil {
+		return "", false
+	}
+	defer C.g_free(C.gpointer(nick))
+
+	return C.GoString((*C.char)(nick)), true
+}
+
 // GoValue converts a Value to comparable Go type.  GoValue()
 // returns a non-nil error if the conversion was unsuccessful.  The
 // returned interface{} must be type asserted as the actual Go
@@ -1391,6 +3068,165 @@ func (v *Value) GetString() (string, error) {
 	return C.GoString((*C.char)(c)), nil
 }
 
+// GetBoolean is a wrapper around g_value_get_boolean(). It returns a non-nil
+// error if v doesn't hold a boolean.
+func (v *Value) GetBoolean() (bool, error) {
+	if err := v.holds(TYPE_BOOLEAN); err != nil {
+		return false, err
+	}
+	return gobool(C.g_value_get_boolean(v.native())), nil
+}
+
+// GetInt is a wrapper around g_value_get_int(). It returns a non-nil error if
+// v doesn't hold an int.
+func (v *Value) GetInt() (int, error) {
+	if err := v.holds(TYPE_INT); err != nil {
+		return 0, err
+	}
+	return int(C.g_value_get_int(v.native())), nil
+}
+
+// GetDouble is a wrapper around g_value_get_double(). It returns a non-nil
+// error if v doesn't hold a double.
+func (v *Value) GetDouble() (float64, error) {
+	if err := v.holds(TYPE_DOUBLE); err != nil {
+		return 0, err
+	}
+	return float64(C.g_value_get_double(v.native())), nil
+}
+
+// GetEnum is a wrapper around g_value_get_enum(). It returns a non-nil error
+// if v doesn't hold an enum.
+func (v *Value) GetEnum() (int, error) {
+	if err := v.holds(TYPE_ENUM); err != nil {
+		return 0, err
+	}
+	return int(C.g_value_get_enum(v.native())), nil
+}
+
+// GetFlags is a wrapper around g_value_get_flags(). It returns a non-nil
+// error if v doesn't hold flags.
+func (v *Value) GetFlags() (uint, error) {
+	if err := v.holds(TYPE_FLAGS); err != nil {
+		return 0, err
+	}
+	return uint(C.g_value_get_flags(v.native())), nil
+}
+
+// GetObject is a wrapper around g_value_get_object(). It returns a non-nil
+// error if v doesn't hold an object, and nil if it holds a NULL object.
+func (v *Value) GetObject() (*Object, error) {
+	if err := v.holds(TYPE_OBJECT); err != nil {
+		return nil, err
+	}
+	c := C.g_value_get_object(v.native())
+	if c == nil {
+		return nil, nil
+	}
+	return Take(unsafe.Pointer(c)), nil
+}
+
+// GetBoxed is a wrapper around g_value_get_boxed(). It returns a non-nil error
+// if v doesn't hold a boxed type. The returned pointer is owned by v.
+func (v *Value) GetBoxed() (unsafe.Pointer, error) {
+	if err := v.holds(TYPE_BOXED); err != nil {
+		return nil, err
+	}
+	return unsafe.Pointer(C.g_value_get_boxed(v.native())), nil
+}
+
+// SetEnum is a wrapper around g_value_set_enum().
+func (v *Value) SetEnum(val int) {
+	C.g_value_set_enum(v.native(), C.gint(val))
+}
+
+// SetFlags is a wrapper around g_value_set_flags().
+func (v *Value) SetFlags(val uint) {
+	C.g_value_set_flags(v.native(), C.guint(val))
+}
+
+// SetObject is a wrapper around g_value_set_object(). A nil obj sets a NULL
+// object.
+func (v *Value) SetObject(obj *Object) {
+	var c C.gpointer
+	if obj != nil {
+		c = C.gpointer(obj.GObject)
+	}
+	C.g_value_set_object(v.native(), c)
+}
+
+// SetBoxed is a wrapper around g_value_set_boxed(). The boxed value p points
+// to is copied.
+func (v *Value) SetBoxed(p unsafe.Pointer) {
+	C.g_value_set_boxed(v.native(), C.gconstpointer(p))
+}
+
+// TransformValue is a wrapper around g_value_transform(). It converts src into
+// the type of dst, returning false if no transformation between the two types
+// is registered.
+func TransformValue(src, dst *Value) bool {
+	return gobool(C.g_value_transform(src.native(), dst.native()))
+}
+
+// ValueTransform is a function converting the value held by src into the type
+// of dst, which is already initialized.
+type ValueTransform func(src, dst *Value)
+
+// valueTransforms holds the Go functions registered using
+// RegisterValueTransform. GLib doesn't give transforms any user data, so the
+// functions are found using the types of the values.
+var valueTransforms = struct {
+	sync.RWMutex
+	funcs map[[2]Type]ValueTransform
+}{
+	funcs: make(map[[2]Type]ValueTransform),
+}
+
+// RegisterValueTransform is a wrapper around
+// g_value_register_transform_func(). It registers fn to transform values of
+// type src into values of type dst, which is then used by TransformValue,
+// g_value_transform() and anything relying on it, such as property bindings.
+// Registering a transform for the same types again replaces the previous one.
+func RegisterValueTransform(src, dst Type, fn ValueTransform) {
+	valueTransforms.Lock()
+	valueTransforms.funcs[[2]Type{src, dst}] = fn
+	valueTransforms.Unlock()
+
+	C._g_value_register_transform_func(C.GType(src), C.GType(dst))
+}
+
+// lookupValueTransform finds the registered transform for the given types. The
+// transform may have been registered for ancestors of the types, the same way
+// GLib looks them up.
+func lookupValueTransform(src, dst Type) ValueTransform {
+	valueTransforms.RLock()
+	defer valueTransforms.RUnlock()
+
+	for s := src; s != TYPE_INVALID; s = s.Parent() {
+		for d := dst; d != TYPE_INVALID; d = d.Parent() {
+			if fn, ok := valueTransforms.funcs[[2]Type{s, d}]; ok {
+				return fn
+			}
+		}
+	}
+
+	return nil
+}
+
+//export goValueTransform
+func goValueTransform(src, dst *C.GValue) {
+	srcType := Type(C._g_value_type(src))
+	dstType := Type(C._g_value_type(dst))
+
+	fn := lookupValueTransform(srcType, dstType)
+	if fn == nil {
+		log.Printf("glib: no Go transform from %s to %s", srcType.Name(), dstType.Name())
+		return
+	}
+
+	fn(&Value{src}, &Value{dst})
+}
+
 type Signal struct {
 	name     string
 	signalId C.guint
@@ -1416,8 +3252,69 @@ func (s *Signal) String() string {
 	return s.name
 }
 
+// SignalFlags is a representation of GLib's GSignalFlags.
+type SignalFlags int
+
+const (
+	SIGNAL_RUN_FIRST    SignalFlags = C.G_SIGNAL_RUN_FIRST
+	SIGNAL_RUN_LAST     SignalFlags = C.G_SIGNAL_RUN_LAST
+	SIGNAL_RUN_CLEANUP  SignalFlags = C.G_SIGNAL_RUN_CLEANUP
+	SIGNAL_NO_RECURSE   SignalFlags = C.G_SIGNAL_NO_RECURSE
+	SIGNAL_DETAILED     SignalFlags = C.G_SIGNAL_DETAILED
+	SIGNAL_ACTION       SignalFlags = C.G_SIGNAL_ACTION
+	SIGNAL_NO_HOOKS     SignalFlags = C.G_SIGNAL_NO_HOOKS
+	SIGNAL_MUST_COLLECT SignalFlags = C.G_SIGNAL_MUST_COLLECT
+	SIGNAL_DEPRECATED   SignalFlags = C.G_SIGNAL_DEPRECATED
+)
+
+// SignalNewv is a wrapper around g_signal_newv(). It registers a new signal
+// on objType, which is usually a type registered using RegisterSubclass, and
+// returns its ID. The signal has no class handler, so it only runs the
+// handlers connected to it, which receive the parameters like for any other
+// signal. returnType may be TYPE_NONE and paramTypes empty for the simplest
+// signals.
+//
+// An error is returned if objType already has a signal of the same name, or if
+// GLib refuses to create the signal.
+func SignalNewv(objType Type, name string, flags SignalFlags, returnType Type, paramTypes []Type) (uint, error) {
+	if SignalLookup(name, objType) != 0 {
+		return 0, fmt.Errorf("%s already has signal %q", objType.Name(), name)
+	}
+
+	cname := (*C.gchar)(C.CString(name))
+	defer C.free(unsafe.Pointer(cname))
+
+	var cparams *C.GType
+	if len(paramTypes) > 0 {
+		params := make([]C.GType, len(paramTypes))
+		for i, t := range paramTypes {
+			params[i] = C.GType(t)
+		}
+		cparams = &params[0]
+	}
+
+	id := C.g_signal_newv(
+		cname, C.GType(objType), C.GSignalFlags(flags),
+		nil, nil, nil, nil,
+		C.GType(returnType), C.guint(len(paramTypes)), cparams,
+	)
+	if id == 0 {
+		return 0, fmt.Errorf("invalid signal %q for %s", name, objType.Name())
+	}
+
+	return uint(id), nil
+}
+
 type Quark uint32
 
+// QuarkFromString is a wrapper around g_quark_from_string().
+func QuarkFromString(s string) Quark {
+	cstr := (*C.gchar)(C.CString(s))
+	defer C.free(unsafe.Pointer(cstr))
+
+	return Quark(C.g_quark_from_string(cstr))
+}
+
 // GetPrgname is a wrapper around g_get_prgname().
 func GetPrgname() string {
 	c := C.g_get_prgname()
@@ -84,6 +84,32 @@ static GType _g_type_from_instance(gpointer instance) {
 }
 
 /* Wrapper to avoid variable arg list */
+static gpointer _g_object_new(GType type) { return g_object_new(type, NULL); }
+
+// Create an object with the given properties. GLib expects an array of
+// GValues, so the values pointed to by values are copied into one first.
+static gpointer _g_object_new_with_properties(GType type, guint n,
+                                              const char **names,
+                                              GValue **values) {
+  GValue *array = g_new0(GValue, n);
+  guint i;
+  gpointer obj;
+
+  for (i = 0; i < n; i++) {
+    g_value_init(&array[i], G_VALUE_TYPE(values[i]));
+    g_value_copy(values[i], &array[i]);
+  }
+
+  obj = g_object_new_with_properties(type, n, names, array);
+
+  for (i = 0; i < n; i++) {
+    g_value_unset(&array[i]);
+  }
+  g_free(array);
+
+  return obj;
+}
+
 static void _g_object_set_one(gpointer object, const gchar *property_name,
                               void *val) {
   g_object_set(object, property_name, *(gpointer **)val, NULL);
@@ -129,10 +155,90 @@ static GType _g_value_fundamental(GType type) {
   return (G_TYPE_FUNDAMENTAL(type));
 }
 
+// Returns a copy of the nick of the enum value held by value, or NULL if the
+// value has no nick.
+static gchar *_g_value_dup_enum_nick(GValue *value) {
+  GEnumClass *klass;
+  GEnumValue *enum_value;
+  gchar *nick = NULL;
+
+  klass = g_type_class_ref(G_VALUE_TYPE(value));
+  enum_value = g_enum_get_value(klass, g_value_get_enum(value));
+  if (enum_value != NULL) {
+    nick = g_strdup(enum_value->value_nick);
+  }
+  g_type_class_unref(klass);
+
+  return nick;
+}
+
+// Looks up the value of the given enum type by nick or name, returning FALSE if
+// there is none.
+static gboolean _g_enum_value_by_nick(GType type, const gchar *nick,
+                                      gint *value) {
+  GEnumClass *klass;
+  GEnumValue *enum_value;
+
+  klass = g_type_class_ref(type);
+  enum_value = g_enum_get_value_by_nick(klass, nick);
+  if (enum_value == NULL) {
+    enum_value = g_enum_get_value_by_name(klass, nick);
+  }
+  if (enum_value != NULL) {
+    *value = enum_value->value;
+  }
+  g_type_class_unref(klass);
+
+  return enum_value != NULL;
+}
+
+// Looks up the value of the given flags type by nick or name, returning FALSE
+// if there is none.
+static gboolean _g_flags_value_by_nick(GType type, const gchar *nick,
+                                       guint *value) {
+  GFlagsClass *klass;
+  GFlagsValue *flags_value;
+
+  klass = g_type_class_ref(type);
+  flags_value = g_flags_get_value_by_nick(klass, nick);
+  if (flags_value == NULL) {
+    flags_value = g_flags_get_value_by_name(klass, nick);
+  }
+  if (flags_value != NULL) {
+    *value = flags_value->value;
+  }
+  g_type_class_unref(klass);
+
+  return flags_value != NULL;
+}
+
+static gboolean _g_value_holds_ptr_array(GValue *value) {
+  return (G_VALUE_HOLDS(value, G_TYPE_PTR_ARRAY));
+}
+
+static gpointer _g_ptr_array_index(GPtrArray *array, guint index) {
+  return (g_ptr_array_index(array, index));
+}
+
+// Returns the return type of the queried signal without the static scope flag.
+static GType _g_signal_query_return_type(GSignalQuery *query) {
+  return (query->return_type & ~G_SIGNAL_TYPE_STATIC_SCOPE);
+}
+
+// Returns the type of the nth parameter of the queried signal without the
+// static scope flag.
+static GType _g_signal_query_param_type(GSignalQuery *query, guint n) {
+  return (query->param_types[n] & ~G_SIGNAL_TYPE_STATIC_SCOPE);
+}
+
 static GObjectClass *_g_object_get_class(GObject *object) {
   return (G_OBJECT_GET_CLASS(object));
 }
 
+static GType _g_param_spec_type(GParamSpec *pspec) {
+  return (G_PARAM_SPEC_TYPE(pspec));
+}
+
 /*
  * Closure support
  */
@@ -140,13 +246,88 @@ static GObjectClass *_g_object_get_class(GObject *object) {
 extern void removeSourceFunc(gpointer data);
 extern gboolean sourceFunc(gpointer data);
 
+// Attach source to context with the Go func stored under data as its callback.
+// The reference on source is released, so the context owns it afterwards.
+static guint _g_source_attach_func(GSource *source, gint priority,
+                                   GMainContext *context, gpointer data) {
+  guint id;
+
+  g_source_set_priority(source, priority);
+  g_source_set_callback(source, sourceFunc, data, removeSourceFunc);
+  id = g_source_attach(source, context);
+  g_source_unref(source);
+
+  return id;
+}
+
 extern void goMarshal(GClosure *, GValue *, guint, GValue *, gpointer,
                       GObject *);
 
+extern void goMarshalUnsafe(GClosure *, GValue *, guint, GValue *, gpointer,
+                            GObject *);
+
 extern void goToggleNotify(gpointer, GObject *, gboolean);
 
 extern void removeClosure(GObject *, GClosure *);
 
+extern void removeUnsafeClosure(GObject *, GClosure *);
+
+extern void goWeakNotify(gpointer, GObject *);
+
+extern void goObjectNativeDestroy(uintptr_t);
+
+extern void goValueTransform(GValue *, GValue *);
+
+extern gboolean goBindingTransformTo(GBinding *, GValue *, GValue *, gpointer);
+extern gboolean goBindingTransformFrom(GBinding *, GValue *, GValue *,
+                                       gpointer);
+extern void goBindingTransformDestroy(gpointer);
+
+// Bind the properties with the Go transform functions stored under data,
+// leaving out the ones that has_to or has_from say are missing.
+static GBinding *_g_object_bind_property_full(
+    gpointer source, const gchar *source_property, gpointer target,
+    const gchar *target_property, GBindingFlags flags, gboolean has_to,
+    gboolean has_from, gpointer data) {
+  return g_object_bind_property_full(
+      source, source_property, target, target_property, flags,
+      has_to ? (GBindingTransformFunc)goBindingTransformTo : NULL,
+      has_from ? (GBindingTransformFunc)goBindingTransformFrom : NULL, data,
+      goBindingTransformDestroy);
+}
+
+static void _g_value_register_transform_func(GType src_type, GType dest_type) {
+  g_value_register_transform_func(src_type, dest_type,
+                                  (GValueTransform)goValueTransform);
+}
+
+static GQuark _go_object_native_quark() {
+  return g_quark_from_static_string("go-glib-object-native");
+}
+
+static uintptr_t _g_object_get_native(GObject *object) {
+  return (uintptr_t)g_object_get_qdata(object, _go_object_native_quark());
+}
+
+static void _g_object_set_native(GObject *object, uintptr_t native) {
+  g_object_set_qdata_full(object, _go_object_native_quark(), (gpointer)native,
+                          (GDestroyNotify)goObjectNativeDestroy);
+}
+
+static void _g_object_steal_native(GObject *object) {
+  g_object_steal_qdata(object, _go_object_native_quark());
+}
+
+extern void goObjectDataDestroy(gpointer data);
+
+// Set the qdata of object to a Go value, given as its callback ID plus one so
+// that it's never NULL. NULL removes the qdata.
+static void _g_object_set_go_qdata(GObject *object, GQuark quark,
+                                   gpointer data) {
+  g_object_set_qdata_full(object, quark, data,
+                          data != NULL ? goObjectDataDestroy : NULL);
+}
+
 static inline guint _g_signal_new(const gchar *name) {
   return g_signal_new(name, G_TYPE_OBJECT, G_SIGNAL_RUN_FIRST | G_SIGNAL_ACTION,
                       0, NULL, NULL, g_cclosure_marshal_VOID__POINTER,
@@ -0,0 +1,98 @@
+// Same copyright and license as the rest of the files in this project
+
+//go:build glib_debug
+// +build glib_debug
+
+package glib
+
+import (
+	"log"
+	"runtime/debug"
+	"sync"
+	"time"
+	"unsafe"
+)
+
+/*
+ * Object debugging
+ *
+ * Building with the glib_debug tag records the stack that created every
+ * wrapped Object and periodically checks for wrappers that are still alive
+ * while their GObject has already been finalized, which means that something
+ * unreferenced the object more than it should have. Such wrappers are logged
+ * along with their creation stack. This is slow and only meant for debugging.
+ */
+
+// debugCheckInterval is the interval at which dangling objects are checked.
+var debugCheckInterval = 10 * time.Second
+
+type debugObject struct {
+	stack    string
+	dangling bool
+	reported bool
+}
+
+var debugObjects = struct {
+	sync.Mutex
+	// Keyed by the address of the objectNative, which must not be kept alive
+	// by this map.
+	objects map[uintptr]*debugObject
+}{
+	objects: make(map[uintptr]*debugObject),
+}
+
+func init() {
+	go func() {
+		for range time.Tick(debugCheckInterval) {
+			checkDanglingObjects()
+		}
+	}()
+}
+
+// debugTrackObject records the stack that created the given objectNative.
+func debugTrackObject(native *objectNative) {
+	stack := string(debug.Stack())
+
+	debugObjects.Lock()
+	debugObjects.objects[uintptr(unsafe.Pointer(native))] = &debugObject{stack: stack}
+	debugObjects.Unlock()
+}
+
+// debugObjectDestroyed marks the given objectNative as dangling, since its
+// GObject was finalized while it's still alive.
+func debugObjectDestroyed(native *objectNative) {
+	debugObjects.Lock()
+	if obj := debugObjects.objects[uintptr(unsafe.Pointer(native))]; obj != nil {
+		obj.dangling = true
+	}
+	debugObjects.Unlock()
+}
+
+// debugUntrackObject forgets the given objectNative once it's finalized.
+func debugUntrackObject(native *objectNative) {
+	debugObjects.Lock()
+	delete(debugObjects.objects, uintptr(unsafe.Pointer(native)))
+	debugObjects.Unlock()
+}
+
+// checkDanglingObjects returns the creation stacks of the objects whose Go
+// wrapper is still alive while their GObject has been finalized. Each of them
+// is logged the first time it's found.
+func checkDanglingObjects() []string {
+	debugObjects.Lock()
+	defer debugObjects.Unlock()
+
+	var stacks []string
+	for _, obj := range debugObjects.objects {
+		if !obj.dangling {
+			continue
+		}
+		if !obj.reported {
+			log.Printf("glib: object finalized while still wrapped, created at:\n%s", obj.stack)
+			obj.reported = true
+		}
+		stacks = append(stacks, obj.stack)
+	}
+
+	return stacks
+}
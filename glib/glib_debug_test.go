@@ -0,0 +1,45 @@
+// Same copyright and license as the rest of the files in this project
+
+//go:build glib_debug
+// +build glib_debug
+
+package glib
+
+import (
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func createdBy(stacks []string, fn string) bool {
+	for _, stack := range stacks {
+		if strings.Contains(stack, fn+"(") {
+			return true
+		}
+	}
+	return false
+}
+
+func TestDetectDanglingObject(t *testing.T) {
+	obj := ObjectNew(TYPE_OBJECT)
+
+	// Release the reference owned by the wrapper behind its back, which
+	// finalizes the object while it's still wrapped.
+	obj.Unref()
+
+	if !createdBy(checkDanglingObjects(), "TestDetectDanglingObject") {
+		t.Error("Expected the dangling object to be detected")
+	}
+
+	runtime.KeepAlive(obj)
+}
+
+func TestLiveObjectNotDangling(t *testing.T) {
+	obj := ObjectNew(TYPE_OBJECT)
+
+	if createdBy(checkDanglingObjects(), "TestLiveObjectNotDangling") {
+		t.Error("Expected a live object not to be detected as dangling")
+	}
+
+	runtime.KeepAlive(obj)
+}
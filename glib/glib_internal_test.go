@@ -0,0 +1,226 @@
+// Same copyright and license as the rest of the files in this project
+
+package glib
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+	"unsafe"
+
+	"github.com/diamondburned/go-glib/core/closure"
+	"github.com/diamondburned/go-glib/glib/internal/testobject"
+)
+
+func TestArgsPoolReset(t *testing.T) {
+	for n := 0; n <= maxPooledArgs+1; n++ {
+		args := getArgs(n)
+		if len(*args) != n {
+			t.Fatal("Expected length", n, "got", len(*args))
+		}
+
+		for i := range *args {
+			(*args)[i] = reflect.ValueOf(i)
+		}
+
+		putArgs(args)
+
+		// The pool may or may not hand back the same slice, but either way it
+		// must not contain anything from the previous borrower.
+		args = getArgs(n)
+		for i, arg := range *args {
+			if arg.IsValid() {
+				t.Error("Expected zero value at", i, "with length", n, "got", arg)
+			}
+		}
+		putArgs(args)
+	}
+}
+
+func TestObjectNativeReuse(t *testing.T) {
+	obj := ObjectNew(TYPE_OBJECT)
+	again := Take(unsafe.Pointer(obj.GObject))
+
+	if again.objectNative != obj.objectNative {
+		t.Error("Expected the same native wrapper, got", again.objectNative, "and", obj.objectNative)
+	}
+}
+
+func TestObjectNativeFinalizeClearsQdata(t *testing.T) {
+	obj := ObjectNew(TYPE_OBJECT)
+	ptr := unsafe.Pointer(obj.GObject)
+
+	// Keep the object alive past the native wrapper.
+	obj.Ref()
+	defer obj.Unref()
+
+	native := obj.objectNative
+	runtime.SetFinalizer(native, nil)
+	finalizeObjectNative(native)
+
+	if loadObjectNative(obj.GObject) != nil {
+		t.Fatal("Expected the qdata to be cleared after finalizing")
+	}
+
+	again := Take(ptr)
+	if again.objectNative == native {
+		t.Error("Expected a new native wrapper after finalizing the old one")
+	}
+}
+
+// addStaleClosure registers a callback on obj as if it were connected and then
+// disconnected without its closure being finalized.
+func addStaleClosure(obj *Object) unsafe.Pointer {
+	// The closure is only compared against the handlers of obj, so any unique
+	// pointer will do.
+	gclosure := unsafe.Pointer(new([64]byte))
+	obj.box.Closures.Register(gclosure, closure.NewFuncStack(func() {}, 0))
+	connectedClosures.Store(gclosure, struct{}{})
+	return gclosure
+}
+
+func TestReapClosures(t *testing.T) {
+	obj := ObjectNew(TYPE_OBJECT)
+	stale := addStaleClosure(obj)
+
+	// A handler that's still connected must be kept.
+	obj.Connect("notify", func() {})
+
+	if n := reapClosures(); n != 1 {
+		t.Error("Expected", 1, "reaped closure, got", n)
+	}
+	if obj.box.Closures.Load(stale) != nil {
+		t.Error("Expected the stale closure to be removed")
+	}
+
+	var live int
+	obj.box.Closures.Range(func(unsafe.Pointer, *closure.FuncStack) bool {
+		live++
+		return true
+	})
+	if live != 1 {
+		t.Error("Expected", 1, "live closure, got", live)
+	}
+
+	runtime.KeepAlive(obj)
+}
+
+func TestClosureReaper(t *testing.T) {
+	obj := ObjectNew(TYPE_OBJECT)
+	stale := addStaleClosure(obj)
+
+	StartClosureReaper(time.Millisecond)
+	defer StopClosureReaper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for obj.box.Closures.Load(stale) != nil {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the reaper to remove the stale closure")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	runtime.KeepAlive(obj)
+}
+
+func TestCheckArgCount(t *testing.T) {
+	fs := closure.NewFuncStack(func(obj *Object, i, j int) {}, 0)
+
+	t.Run("enough", func(t *testing.T) {
+		checkArgCount(fs, 3)
+	})
+
+	t.Run("panic", func(t *testing.T) {
+		defer func() {
+			if recover() == nil {
+				t.Error("expected panic, did not get one")
+			}
+		}()
+
+		checkArgCount(fs, 2)
+	})
+
+	t.Run("warn", func(t *testing.T) {
+		var buf bytes.Buffer
+		log.SetOutput(&buf)
+		defer log.SetOutput(os.Stderr)
+
+		WarnOnExtraArgs = true
+		defer func() { WarnOnExtraArgs = false }()
+
+		checkArgCount(fs, 2)
+
+		if !strings.Contains(buf.String(), "too many closure args") {
+			t.Error("Expected a warning, got", buf.String())
+		}
+	})
+}
+
+func TestConnectOnceRemovesClosure(t *testing.T) {
+	obj := ObjectNew(Type(testobject.Type()))
+	obj.ConnectOnce("no-args", func() {})
+
+	testobject.EmitNoArgs(obj.Native())
+
+	var n int
+	obj.box.Closures.Range(func(unsafe.Pointer, *closure.FuncStack) bool {
+		n++
+		return true
+	})
+	if n != 0 {
+		t.Error("Expected the closure to be removed, got", n, "closures")
+	}
+}
+
+func TestDynamicTypeReloadProperties(t *testing.T) {
+	var inits int
+	plugin := NewTypePlugin(nil, nil)
+	typ := RegisterDynamicType(plugin, TYPE_OBJECT, "GoGlibTestReloaded", func(klass *ObjectClass) {
+		inits++
+		init := inits
+
+		klass.InstallProperty(
+			ParamSpecInt("init", "Init", "The class init", 0, 100, 0, PARAM_READABLE),
+			func(obj *Object, value *Value) { value.SetInt(init) },
+			nil,
+		)
+	}, nil)
+
+	var handlers int
+	for i := 1; i <= 2; i++ {
+		obj := ObjectNew(typ)
+
+		init, err := obj.GetProperty("init")
+		if err != nil {
+			t.Fatal("Failed to get init:", err)
+		}
+		if init != i {
+			t.Error("Expected", i, "got", init)
+		}
+
+		properties.RLock()
+		n := len(properties.handlers)
+		properties.RUnlock()
+
+		if i == 1 {
+			handlers = n
+		} else if n != handlers {
+			t.Error("Expected", handlers, "property handlers after reloading, got", n)
+		}
+
+		// The class is finalized along with its only instance, and initialized
+		// again by the next one.
+		if !WaitForFinalization(obj, 5*time.Second) {
+			t.Fatal("Expected the object to be finalized")
+		}
+	}
+
+	if inits != 2 {
+		t.Error("Expected", 2, "class inits, got", inits)
+	}
+}
@@ -0,0 +1,14 @@
+// Same copyright and license as the rest of the files in this project
+
+//go:build !glib_debug
+// +build !glib_debug
+
+package glib
+
+// See glib_debug.go.
+
+func debugTrackObject(native *objectNative) {}
+
+func debugObjectDestroyed(native *objectNative) {}
+
+func debugUntrackObject(native *objectNative) {}
@@ -0,0 +1,1200 @@
+// Same copyright and license as the rest of the files in this project
+
+package glib_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+	"unsafe"
+
+	"github.com/diamondburned/go-glib/glib"
+	"github.com/diamondburned/go-glib/glib/internal/testobject"
+)
+
+func TestCountNotifies(t *testing.T) {
+	obj := newTestObject()
+
+	count := obj.CountNotifies("int", func() {
+		obj.SetProperty("int", 1)
+		obj.SetProperty("int", 2)
+	})
+	if count != 2 {
+		t.Error("Expected", 2, "got", count)
+	}
+
+	count = obj.CountNotifies("int", func() {
+		obj.FreezeNotify()
+		obj.SetProperty("int", 3)
+		obj.SetProperty("int", 4)
+		obj.ThawNotify()
+	})
+	if count != 1 {
+		t.Error("Expected", 1, "got", count)
+	}
+
+	count = obj.CountNotifies("int", func() {
+		obj.SetProperty("string", "unrelated")
+	})
+	if count != 0 {
+		t.Error("Expected", 0, "got", count)
+	}
+}
+
+func TestSetPropertyIfChanged(t *testing.T) {
+	obj := newTestObject()
+
+	var changes []bool
+
+	count := obj.CountNotifies("int", func() {
+		for _, value := range []int{5, 5, 6} {
+			changed, err := obj.SetPropertyIfChanged("int", value)
+			if err != nil {
+				t.Fatal("Unexpected error:", err)
+			}
+			changes = append(changes, changed)
+		}
+	})
+	if count != 2 {
+		t.Error("Expected", 2, "got", count)
+	}
+
+	expected := []bool{true, false, true}
+	for i := range expected {
+		if changes[i] != expected[i] {
+			t.Error("Expected", expected, "got", changes)
+			break
+		}
+	}
+
+	if _, err := obj.SetPropertyIfChanged("missing", 1); err == nil {
+		t.Error("Expected error for missing property")
+	}
+}
+
+func TestSetPropertyIfChangedTypes(t *testing.T) {
+	next := glib.ObjectNew(glib.TYPE_OBJECT)
+
+	testCases := []struct {
+		desc     string
+		obj      *glib.Object
+		name     string
+		values   []interface{}
+		expected []bool
+	}{
+		{
+			desc:     "enum",
+			obj:      newTestObject(),
+			name:     "mode",
+			values:   []interface{}{testobject.ModeSlow, testobject.ModeSlow, testobject.ModeFast},
+			expected: []bool{true, false, true},
+		},
+		{
+			desc:     "object",
+			obj:      glib.ObjectNew(nodeType),
+			name:     "next",
+			values:   []interface{}{next, next, *next, nil},
+			expected: []bool{true, false, false, true},
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			var changes []bool
+			var expectedCount int
+
+			count := tC.obj.CountNotifies(tC.name, func() {
+				for i, value := range tC.values {
+					changed, err := tC.obj.SetPropertyIfChanged(tC.name, value)
+					if err != nil {
+						t.Fatal("Unexpected error:", err)
+					}
+					changes = append(changes, changed)
+
+					if tC.expected[i] {
+						expectedCount++
+					}
+				}
+			})
+			if count != expectedCount {
+				t.Error("Expected", expectedCount, "got", count)
+			}
+
+			for i := range tC.expected {
+				if changes[i] != tC.expected[i] {
+					t.Error("Expected", tC.expected, "got", changes)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestEmitValues(t *testing.T) {
+	obj := newTestObject()
+
+	type args struct {
+		i int
+		s string
+	}
+
+	var got []args
+	obj.Connect("int-string", func(obj *glib.Object, i int, s string) {
+		got = append(got, args{i, s})
+	})
+
+	obj.Emit("int-string", 7, "seven")
+
+	id := glib.SignalLookup("int-string", obj.TypeFromInstance())
+	if id == 0 {
+		t.Fatal("Failed to look up signal int-string")
+	}
+
+	i, _ := glib.GValue(7)
+	s, _ := glib.GValue("seven")
+	params := []*glib.Value{i, s}
+
+	// Reuse the same values across emissions.
+	for n := 0; n < 2; n++ {
+		ret, err := obj.EmitValues(id, 0, params)
+		if err != nil {
+			t.Fatal("Unexpected error:", err)
+		}
+		if ret != nil {
+			t.Error("Expected no return value, got", ret)
+		}
+	}
+
+	if len(got) != 3 {
+		t.Fatal("Expected", 3, "emissions, got", len(got))
+	}
+	for _, emission := range got[1:] {
+		if emission != got[0] {
+			t.Error("Expected", got[0], "got", emission)
+		}
+	}
+
+	if _, err := obj.EmitValues(id, 0, params[:1]); err == nil {
+		t.Error("Expected error for missing parameters")
+	}
+}
+
+func TestEmit(t *testing.T) {
+	t.Run("no return value", func(t *testing.T) {
+		obj := newTestObject()
+
+		var got int
+		obj.Connect("int", func(obj *glib.Object, i int) { got = i })
+
+		ret, err := obj.Emit("int", 5)
+		if err != nil {
+			t.Fatal("Unexpected error:", err)
+		}
+		if ret != nil {
+			t.Error("Expected no return value, got", ret)
+		}
+		if got != 5 {
+			t.Error("Expected", 5, "got", got)
+		}
+	})
+
+	t.Run("return value", func(t *testing.T) {
+		obj := newTestObject()
+		obj.Connect("handled", func() bool { return true })
+
+		ret, err := obj.Emit("handled")
+		if err != nil {
+			t.Fatal("Unexpected error:", err)
+		}
+		if ret != true {
+			t.Error("Expected", true, "got", ret)
+		}
+	})
+
+	t.Run("detail", func(t *testing.T) {
+		obj := newTestObject()
+
+		var ints, strs int
+		obj.Connect("notify::int", func() { ints++ })
+		obj.Connect("notify::string", func() { strs++ })
+
+		if _, err := obj.Emit("notify::int", obj.FindProperty("int")); err != nil {
+			t.Fatal("Unexpected error:", err)
+		}
+		if ints != 1 || strs != 0 {
+			t.Error("Expected only the int handler to be called, got", ints, "and", strs)
+		}
+	})
+
+	testCases := []struct {
+		desc   string
+		signal string
+		args   []interface{}
+	}{
+		{desc: "missing signal", signal: "missing"},
+		{desc: "too few args", signal: "int"},
+		{desc: "too many args", signal: "int", args: []interface{}{1, 2}},
+		{desc: "unconvertible arg", signal: "enum", args: []interface{}{"fast"}},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			if _, err := newTestObject().Emit(tC.signal, tC.args...); err == nil {
+				t.Error("Expected error emitting", tC.signal, "with", tC.args)
+			}
+		})
+	}
+}
+
+func TestEmitAccumulated(t *testing.T) {
+	t.Run("first wins", func(t *testing.T) {
+		obj := newTestObject()
+
+		var calls int
+		obj.Connect("first", func() string { calls++; return "first" })
+		obj.Connect("first", func() string { calls++; return "second" })
+
+		ret, err := obj.Emit("first")
+		if err != nil {
+			t.Fatal("Unexpected error:", err)
+		}
+		if ret != "first" {
+			t.Error("Expected", "first", "got", ret)
+		}
+		if calls != 1 {
+			t.Error("Expected", 1, "call, got", calls)
+		}
+	})
+
+	t.Run("first non-nil", func(t *testing.T) {
+		obj := newTestObject()
+		other := newTestObject()
+
+		obj.Connect("first-object", func() *glib.Object { return nil })
+		obj.Connect("first-object", func() *glib.Object { return other })
+
+		ret, err := obj.Emit("first-object")
+		if err != nil {
+			t.Fatal("Unexpected error:", err)
+		}
+		got, ok := ret.(*glib.Object)
+		if !ok || got == nil || got.Native() != other.Native() {
+			t.Error("Expected", other, "got", ret)
+		}
+	})
+}
+
+func TestEmitAsync(t *testing.T) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	ctx := glib.MainContextDefault()
+	if !ctx.Acquire() {
+		t.Fatal("Failed to acquire context")
+	}
+	defer ctx.Release()
+
+	obj := newTestObject()
+
+	var (
+		gotInt    int
+		gotString string
+		onMain    bool
+	)
+	done := make(chan struct{})
+
+	obj.Connect("int-string", func(obj *glib.Object, i int, s string) {
+		gotInt, gotString = i, s
+		onMain = ctx.IsOwner()
+		close(done)
+	})
+
+	go obj.EmitAsync("int-string", 5, "foo")
+
+	deadline := time.Now().Add(5 * time.Second)
+	for waiting := true; waiting; {
+		select {
+		case <-done:
+			waiting = false
+		default:
+			if time.Now().After(deadline) {
+				t.Fatal("signal was not emitted")
+			}
+			if !ctx.Iteration(false) {
+				time.Sleep(time.Millisecond)
+			}
+		}
+	}
+
+	if gotInt != 5 || gotString != "foo" {
+		t.Error("Expected", 5, "foo", "got", gotInt, gotString)
+	}
+	if !onMain {
+		t.Error("Expected the handler to run on the main thread")
+	}
+}
+
+func TestRegisterValueTransform(t *testing.T) {
+	// Transforms can't be unregistered, so use types private to the tests to
+	// not affect value conversions elsewhere. GLib has no transform between
+	// enums and flags by default.
+	modeType := glib.Type(testobject.ModeType())
+	flagsType := glib.Type(testobject.FlagsType())
+
+	// GO_GLIB_TEST_MODE_FAST maps to GO_GLIB_TEST_FLAGS_READ and
+	// GO_GLIB_TEST_MODE_SLOW to GO_GLIB_TEST_FLAGS_WRITE.
+	glib.RegisterValueTransform(modeType, flagsType, func(src, dst *glib.Value) {
+		mode, _ := src.GetEnum()
+		if mode > 0 {
+			dst.SetFlags(1 << uint(mode-1))
+		}
+	})
+
+	src, _ := glib.ValueInit(modeType)
+	src.SetEnum(2)
+	dst, _ := glib.ValueInit(flagsType)
+
+	if !glib.TransformValue(src, dst) {
+		t.Fatal("Expected the transform to succeed")
+	}
+
+	v, err := dst.GetFlags()
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if v != 2 {
+		t.Error("Expected", 2, "got", v)
+	}
+}
+
+func TestWithFrozenNotify(t *testing.T) {
+	obj := newTestObject()
+
+	count := obj.CountNotifies("int", func() {
+		obj.WithFrozenNotify(func() {
+			obj.SetProperty("int", 1)
+			obj.SetProperty("int", 2)
+			obj.SetProperty("int", 3)
+		})
+	})
+	if count != 1 {
+		t.Error("Expected", 1, "got", count)
+	}
+
+	func() {
+		defer func() { recover() }()
+
+		obj.WithFrozenNotify(func() {
+			obj.SetProperty("int", 4)
+			panic("in scope")
+		})
+	}()
+
+	// Notifications must have been thawed by the panic.
+	count = obj.CountNotifies("int", func() {
+		obj.SetProperty("int", 5)
+	})
+	if count != 1 {
+		t.Error("Expected", 1, "after panic, got", count)
+	}
+}
+
+func TestWaitForFinalization(t *testing.T) {
+	if !glib.WaitForFinalization(newTestObject(), 5*time.Second) {
+		t.Error("Expected a disposable object to be finalized")
+	}
+
+	leaked := newTestObject()
+	leaked.Ref()
+
+	if glib.WaitForFinalization(leaked, 100*time.Millisecond) {
+		t.Error("Expected a leaked object not to be finalized")
+	}
+}
+
+func TestCopyProperties(t *testing.T) {
+	src := newTestObject()
+	src.SetProperty("int", 7)
+	src.SetProperty("string", "copied")
+	src.SetProperty("boolean", true)
+	src.SetProperty("double", 1.5)
+
+	t.Run("named", func(t *testing.T) {
+		dst := newTestObject()
+
+		if err := glib.CopyProperties(src, dst, "int", "string"); err != nil {
+			t.Fatal("Unexpected error:", err)
+		}
+
+		expectProperties(t, dst, map[string]interface{}{
+			"int":     7,
+			"string":  "copied",
+			"boolean": false,
+			"double":  0.0,
+		})
+	})
+
+	t.Run("all", func(t *testing.T) {
+		dst := newTestObject()
+
+		if err := glib.CopyProperties(src, dst); err != nil {
+			t.Fatal("Unexpected error:", err)
+		}
+
+		expectProperties(t, dst, map[string]interface{}{
+			"int":     7,
+			"string":  "copied",
+			"boolean": true,
+			"double":  1.5,
+		})
+	})
+
+	t.Run("missing", func(t *testing.T) {
+		if err := glib.CopyProperties(src, newTestObject(), "missing"); err == nil {
+			t.Error("Expected error for missing property")
+		}
+	})
+}
+
+func TestSetPropertyFromString(t *testing.T) {
+	obj := newTestObject()
+
+	values := map[string]string{
+		"int":     "42",
+		"boolean": "true",
+		"string":  "verbatim",
+		"double":  "1.5",
+		"mode":    "fast",
+	}
+	for name, value := range values {
+		if err := obj.SetPropertyFromString(name, value); err != nil {
+			t.Error("Unexpected error setting", name+":", err)
+		}
+	}
+
+	expectProperties(t, obj, map[string]interface{}{
+		"int":     42,
+		"boolean": true,
+		"string":  "verbatim",
+		"double":  1.5,
+		"mode":    testobject.ModeFast,
+	})
+
+	testCases := []struct {
+		desc  string
+		name  string
+		value string
+	}{
+		{desc: "invalid int", name: "int", value: "forty-two"},
+		{desc: "invalid boolean", name: "boolean", value: "maybe"},
+		{desc: "unknown enum", name: "mode", value: "sideways"},
+		{desc: "missing property", name: "missing", value: "1"},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			if err := obj.SetPropertyFromString(tC.name, tC.value); err == nil {
+				t.Error("Expected error setting", tC.name, "to", tC.value)
+			}
+		})
+	}
+}
+
+func TestGetPropertiesAsVariant(t *testing.T) {
+	obj := newTestObject()
+	obj.SetProperty("int", 7)
+	obj.SetProperty("string", "seven")
+	obj.SetProperty("boolean", true)
+	obj.SetProperty("double", 1.5)
+	obj.SetPropertyFromString("mode", "slow")
+
+	t.Run("named", func(t *testing.T) {
+		dict := obj.GetPropertiesAsVariant("int", "string", "mode")
+		if dict == nil {
+			t.Fatal("Expected a dictionary, got nil")
+		}
+
+		expected := "{'int': <7>, 'string': <'seven'>, 'mode': <2>}"
+		if actual := dict.String(); actual != expected {
+			t.Error("Expected", expected, "got", actual)
+		}
+	})
+
+	t.Run("all", func(t *testing.T) {
+		dict := obj.GetPropertiesAsVariant()
+		if dict == nil {
+			t.Fatal("Expected a dictionary, got nil")
+		}
+		if ts := dict.TypeString(); ts != "a{sv}" {
+			t.Error("Expected", "a{sv}", "got", ts)
+		}
+
+		expected := map[string]string{
+			"int":     "7",
+			"string":  "'seven'",
+			"boolean": "true",
+			"double":  "1.5",
+			"mode":    "2",
+		}
+		for key, value := range expected {
+			actual := dict.LookupValue(key, nil)
+			if actual == nil {
+				t.Error("Expected", key, "in", dict)
+				continue
+			}
+			if actual.String() != value {
+				t.Error("Expected", key, "to be", value, "got", actual)
+			}
+		}
+	})
+
+	t.Run("missing", func(t *testing.T) {
+		if dict := obj.GetPropertiesAsVariant("int", "missing"); dict != nil {
+			t.Error("Expected nil, got", dict)
+		}
+	})
+}
+
+func TestApplyVariantDict(t *testing.T) {
+	dict, err := glib.VariantParse(glib.VARIANT_TYPE_VARDICT,
+		"{'int': <7>, 'string': <'seven'>, 'double': <1.5>, 'mode': <2>, 'missing': <true>}")
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	obj := newTestObject()
+	if err := obj.ApplyVariantDict(dict); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+
+	expectProperties(t, obj, map[string]interface{}{
+		"int":    7,
+		"string": "seven",
+		"double": 1.5,
+		"mode":   testobject.ModeSlow,
+	})
+
+	if !strings.Contains(buf.String(), `"missing"`) {
+		t.Error("Expected a warning about the unknown key, got", buf.String())
+	}
+
+	t.Run("round trip", func(t *testing.T) {
+		dst := newTestObject()
+		if err := dst.ApplyVariantDict(obj.GetPropertiesAsVariant()); err != nil {
+			t.Fatal("Unexpected error:", err)
+		}
+
+		expectProperties(t, dst, map[string]interface{}{
+			"int":    7,
+			"string": "seven",
+			"double": 1.5,
+			"mode":   testobject.ModeSlow,
+		})
+	})
+
+	t.Run("invalid value", func(t *testing.T) {
+		dict, _ := glib.VariantParse(glib.VARIANT_TYPE_VARDICT, "{'int': <[1, 2]>}")
+		if err := newTestObject().ApplyVariantDict(dict); err == nil {
+			t.Error("Expected error for an array int")
+		}
+	})
+
+	t.Run("not a dictionary", func(t *testing.T) {
+		if err := newTestObject().ApplyVariantDict(glib.VariantFromInt32(1)); err == nil {
+			t.Error("Expected error for a non-dictionary")
+		}
+	})
+}
+
+func TestFindProperty(t *testing.T) {
+	obj := newTestObject()
+
+	pspec := obj.FindProperty("int")
+	if pspec == nil {
+		t.Fatal("Expected to find property int")
+	}
+	if pspec.Name() != "int" {
+		t.Error("Expected", "int", "got", pspec.Name())
+	}
+	if pspec.ValueType() != glib.TYPE_INT {
+		t.Error("Expected", glib.TYPE_INT, "got", pspec.ValueType())
+	}
+
+	if pspec := obj.FindProperty("missing"); pspec != nil {
+		t.Error("Expected nil for missing property, got", pspec)
+	}
+}
+
+func TestWrapTwiceBalanced(t *testing.T) {
+	obj := newTestObject()
+	ptr := obj.Native()
+
+	// Hold a reference from "C" for the whole test, so that over-releasing
+	// would finalize the object early.
+	obj.Ref()
+
+	glib.Take(unsafe.Pointer(ptr))
+	glib.Take(unsafe.Pointer(ptr))
+
+	// Transfer full, which AssumeOwnership consumes.
+	obj.Ref()
+	glib.AssumeOwnership(unsafe.Pointer(ptr))
+
+	if glib.WaitForFinalization(obj, 100*time.Millisecond) {
+		t.Fatal("Expected the object to outlive its Go wrappers")
+	}
+
+	again := glib.Take(unsafe.Pointer(ptr))
+	again.Unref()
+
+	if !glib.WaitForFinalization(again, 5*time.Second) {
+		t.Error("Expected the object to be finalized after releasing the last reference")
+	}
+}
+
+func TestToggleRefExternalOwner(t *testing.T) {
+	var called int
+	ptr := func() uintptr {
+		obj := newTestObject()
+		obj.Connect("no-args", func() { called++ })
+
+		// Act as C code that keeps the object after Go drops its wrapper.
+		obj.Ref()
+		return obj.Native()
+	}()
+
+	// The wrapper is unreachable now, but the external reference must keep
+	// both the object and its closures alive.
+	for i := 0; i < 5; i++ {
+		runtime.GC()
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	testobject.EmitNoArgs(ptr)
+	if called != 1 {
+		t.Fatal("Expected the handler to survive GC, got", called, "calls")
+	}
+
+	// Hand the external reference back to Go.
+	obj := glib.AssumeOwnership(unsafe.Pointer(ptr))
+	if !glib.WaitForFinalization(obj, 5*time.Second) {
+		t.Error("Expected the object to be finalized after releasing the external reference")
+	}
+}
+
+func TestFloatingReference(t *testing.T) {
+	t.Run("object new", func(t *testing.T) {
+		obj := glib.ObjectNew(glib.TypeFromName("GInitiallyUnowned"))
+		if obj.IsFloating() {
+			t.Fatal("Expected the floating reference to be sunk")
+		}
+		if n := testobject.RefCount(obj.Native()); n != 1 {
+			t.Error("Expected", 1, "reference, got", n)
+		}
+
+		// Sinking again, like a container taking the object would, must take
+		// a reference of its own.
+		obj.RefSink()
+		if n := testobject.RefCount(obj.Native()); n != 2 {
+			t.Error("Expected", 2, "references, got", n)
+		}
+		obj.Unref()
+
+		if !glib.WaitForFinalization(obj, 5*time.Second) {
+			t.Error("Expected the object to be finalized")
+		}
+	})
+
+	t.Run("take", func(t *testing.T) {
+		// Unlike ObjectNew, Take doesn't own the floating reference, so it
+		// must not sink it.
+		obj := glib.Take(testobject.NewFloating())
+		if !obj.IsFloating() {
+			t.Fatal("Expected the floating reference to be left alone")
+		}
+		if n := testobject.RefCount(obj.Native()); n != 2 {
+			t.Error("Expected", 2, "references, got", n)
+		}
+
+		// Release the floating reference like its owner would.
+		obj.RefSink()
+		obj.Unref()
+
+		if !glib.WaitForFinalization(obj, 5*time.Second) {
+			t.Error("Expected the object to be finalized")
+		}
+	})
+
+	t.Run("manual", func(t *testing.T) {
+		obj := glib.ObjectNew(glib.TYPE_OBJECT)
+		obj.ForceFloating()
+		if !obj.IsFloating() {
+			t.Fatal("Expected the object to be floating")
+		}
+
+		// Sinking a floating reference doesn't take another one.
+		obj.RefSink()
+		if obj.IsFloating() {
+			t.Error("Expected the floating reference to be sunk")
+		}
+		if n := testobject.RefCount(obj.Native()); n != 1 {
+			t.Error("Expected", 1, "reference, got", n)
+		}
+	})
+}
+
+func TestObjectData(t *testing.T) {
+	type data struct {
+		name   string
+		marker *glib.Object
+	}
+
+	obj := newTestObject()
+
+	if got := obj.GetData("missing"); got != nil {
+		t.Error("Expected nil for a missing key, got", got)
+	}
+
+	marker := glib.ObjectNew(glib.TYPE_OBJECT)
+	obj.SetData("key", &data{name: "first", marker: marker})
+
+	runtime.GC()
+
+	if got, ok := obj.GetData("key").(*data); !ok || got.name != "first" {
+		t.Fatal("Expected the data to survive GC, got", obj.GetData("key"))
+	}
+
+	// Overwriting the key must release the previous data.
+	obj.SetData("key", &data{name: "second"})
+	if got := obj.GetData("key").(*data); got.name != "second" {
+		t.Error("Expected", "second", "got", got.name)
+	}
+	if !glib.WaitForFinalization(marker, 5*time.Second) {
+		t.Error("Expected the previous data to be released")
+	}
+
+	obj.SetData("key", nil)
+	if got := obj.GetData("key"); got != nil {
+		t.Error("Expected nil after removing the data, got", got)
+	}
+}
+
+func TestGetPropertyDefault(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		obj      *glib.Object
+		name     string
+		value    interface{}
+		expected interface{}
+	}{
+		{desc: "int", obj: newTestObject(), name: "int", value: 5, expected: 0},
+		{desc: "string", obj: newTestObject(), name: "string", value: "set", expected: ""},
+		{desc: "overridden int", obj: glib.ObjectNew(levelImplType), name: "level", value: 5, expected: 10},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			// The default must not depend on the current value.
+			tC.obj.SetProperty(tC.name, tC.value)
+
+			def, err := tC.obj.GetPropertyDefault(tC.name)
+			if err != nil {
+				t.Fatal("Unexpected error:", err)
+			}
+			if def != tC.expected {
+				t.Error("Expected", tC.expected, "got", def)
+			}
+		})
+	}
+
+	if _, err := newTestObject().GetPropertyDefault("missing"); err == nil {
+		t.Error("Expected error for missing property")
+	}
+}
+
+// nodeNexts holds the "next" property of the nodeType objects.
+var nodeNexts = map[uintptr]*glib.Object{}
+
+var nodeType = glib.RegisterSubclass(glib.TYPE_OBJECT, "GoGlibTestNode", func(klass *glib.ObjectClass) {
+	klass.InstallProperty(
+		glib.ParamSpecObject("next", "Next", "The next node", glib.TYPE_OBJECT, glib.PARAM_READWRITE),
+		func(obj *glib.Object, value *glib.Value) {
+			if next := nodeNexts[obj.Native()]; next != nil {
+				value.SetInstance(next.Native())
+			}
+		},
+		func(obj *glib.Object, value *glib.Value) {
+			next, _ := value.GoValue()
+			nodeNexts[obj.Native()], _ = next.(*glib.Object)
+		},
+	)
+}, nil)
+
+func TestDetectCycle(t *testing.T) {
+	newNodes := func(n int) []*glib.Object {
+		nodes := make([]*glib.Object, n)
+		for i := range nodes {
+			nodes[i] = glib.ObjectNew(nodeType)
+		}
+		for i := 0; i < n-1; i++ {
+			nodes[i].SetProperty("next", nodes[i+1])
+		}
+		return nodes
+	}
+
+	t.Run("none", func(t *testing.T) {
+		nodes := newNodes(3)
+
+		if path := glib.DetectCycle(nodes[0]); path != nil {
+			t.Error("Expected no cycle, got", path)
+		}
+	})
+
+	t.Run("cycle", func(t *testing.T) {
+		nodes := newNodes(3)
+		nodes[2].SetProperty("next", nodes[0])
+
+		path := glib.DetectCycle(nodes[0])
+		if len(path) != 3 {
+			t.Fatal("Expected a path of", 3, "properties, got", path)
+		}
+		for _, name := range path {
+			if name != "next" {
+				t.Error("Expected only next properties, got", path)
+				break
+			}
+		}
+	})
+
+	t.Run("cycle not through root", func(t *testing.T) {
+		nodes := newNodes(3)
+		nodes[2].SetProperty("next", nodes[1])
+
+		if path := glib.DetectCycle(nodes[0]); path != nil {
+			t.Error("Expected no cycle back to the root, got", path)
+		}
+	})
+}
+
+func TestRuntimeVersion(t *testing.T) {
+	major, minor, micro := glib.RuntimeVersion()
+	if major != 2 {
+		t.Error("Expected major version", 2, "got", major)
+	}
+	if minor < 36 {
+		t.Error("Expected minor version of at least", 36, "got", minor)
+	}
+
+	if err := glib.CheckVersion(major, minor, micro); err != nil {
+		t.Error("Expected the runtime version to be compatible with itself, got", err)
+	}
+	if err := glib.CheckVersion(2, 0, 0); err != nil {
+		t.Error("Expected an old version to be compatible, got", err)
+	}
+	if err := glib.CheckVersion(2, 9999, 0); err == nil {
+		t.Error("Expected a future version to be incompatible")
+	}
+	if err := glib.CheckVersion(3, 0, 0); err == nil {
+		t.Error("Expected another major version to be incompatible")
+	}
+}
+
+func TestTakeErrorCancelled(t *testing.T) {
+	// G_IO_ERROR_CANCELLED in the G_IO_ERROR domain.
+	err := glib.TakeError(testobject.NewError("g-io-error-quark", 19, "Operation was cancelled"))
+	if !errors.Is(err, context.Canceled) {
+		t.Error("Expected", context.Canceled, "got", err)
+	}
+	if err.Error() != "Operation was cancelled" {
+		t.Error("Expected", "Operation was cancelled", "got", err.Error())
+	}
+
+	err = glib.TakeError(testobject.NewError("g-io-error-quark", 1, "Not found"))
+	if errors.Is(err, context.Canceled) {
+		t.Error("Expected an error other than", context.Canceled, "got", err)
+	}
+
+	if err := glib.TakeError(nil); err != nil {
+		t.Error("Expected nil, got", err)
+	}
+}
+
+func TestGError(t *testing.T) {
+	err := glib.TakeError(testobject.NewError("go-glib-test-error", 3, "Something broke"))
+
+	var gerr *glib.GError
+	if !errors.As(err, &gerr) {
+		t.Fatal("Expected a *glib.GError, got", err)
+	}
+
+	if gerr.Error() != "Something broke" {
+		t.Error("Expected", "Something broke", "got", gerr.Error())
+	}
+	if gerr.Domain() != "go-glib-test-error" {
+		t.Error("Expected", "go-glib-test-error", "got", gerr.Domain())
+	}
+	if gerr.Code() != 3 {
+		t.Error("Expected", 3, "got", gerr.Code())
+	}
+
+	testCases := []struct {
+		desc   string
+		domain string
+		code   int
+		want   bool
+	}{
+		{desc: "same", domain: "go-glib-test-error", code: 3, want: true},
+		{desc: "other code", domain: "go-glib-test-error", code: 4, want: false},
+		{desc: "other domain", domain: "g-io-error-quark", code: 3, want: false},
+		{desc: "unknown domain", domain: "go-glib-unknown-error", code: 3, want: false},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			if got := gerr.Matches(tC.domain, tC.code); got != tC.want {
+				t.Error("Expected", tC.want, "got", got)
+			}
+		})
+	}
+}
+
+func TestValueGettersSetters(t *testing.T) {
+	obj := newTestObject()
+
+	testCases := []struct {
+		desc     string
+		typ      glib.Type
+		set      func(v *glib.Value)
+		get      func(v *glib.Value) (interface{}, error)
+		expected interface{}
+	}{
+		{
+			desc:     "boolean",
+			typ:      glib.TYPE_BOOLEAN,
+			set:      func(v *glib.Value) { v.SetBool(true) },
+			get:      func(v *glib.Value) (interface{}, error) { return v.GetBoolean() },
+			expected: true,
+		},
+		{
+			desc:     "int",
+			typ:      glib.TYPE_INT,
+			set:      func(v *glib.Value) { v.SetInt(-5) },
+			get:      func(v *glib.Value) (interface{}, error) { return v.GetInt() },
+			expected: -5,
+		},
+		{
+			desc:     "double",
+			typ:      glib.TYPE_DOUBLE,
+			set:      func(v *glib.Value) { v.SetDouble(2.5) },
+			get:      func(v *glib.Value) (interface{}, error) { return v.GetDouble() },
+			expected: 2.5,
+		},
+		{
+			desc:     "string",
+			typ:      glib.TYPE_STRING,
+			set:      func(v *glib.Value) { v.SetString("foo") },
+			get:      func(v *glib.Value) (interface{}, error) { return v.GetString() },
+			expected: "foo",
+		},
+		{
+			desc:     "empty string",
+			typ:      glib.TYPE_STRING,
+			set:      func(v *glib.Value) { v.SetString("") },
+			get:      func(v *glib.Value) (interface{}, error) { return v.GetString() },
+			expected: "",
+		},
+		{
+			desc:     "enum",
+			typ:      glib.Type(testobject.ModeType()),
+			set:      func(v *glib.Value) { v.SetEnum(testobject.ModeSlow) },
+			get:      func(v *glib.Value) (interface{}, error) { return v.GetEnum() },
+			expected: testobject.ModeSlow,
+		},
+		{
+			desc:     "flags",
+			typ:      glib.Type(testobject.FlagsType()),
+			set:      func(v *glib.Value) { v.SetFlags(testobject.FlagsRead | testobject.FlagsWrite) },
+			get:      func(v *glib.Value) (interface{}, error) { return v.GetFlags() },
+			expected: testobject.FlagsRead | testobject.FlagsWrite,
+		},
+		{
+			desc:     "object",
+			typ:      glib.TYPE_OBJECT,
+			set:      func(v *glib.Value) { v.SetObject(obj) },
+			get:      func(v *glib.Value) (interface{}, error) { return v.GetObject() },
+			expected: obj,
+		},
+		{
+			desc:     "nil object",
+			typ:      glib.TYPE_OBJECT,
+			set:      func(v *glib.Value) { v.SetObject(nil) },
+			get:      func(v *glib.Value) (interface{}, error) { return v.GetObject() },
+			expected: (*glib.Object)(nil),
+		},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			v, err := glib.ValueInit(tC.typ)
+			if err != nil {
+				t.Fatal("Unexpected error:", err)
+			}
+			tC.set(v)
+
+			got, err := tC.get(v)
+			if err != nil {
+				t.Fatal("Unexpected error:", err)
+			}
+			if got, ok := got.(*glib.Object); ok && got != nil {
+				if got.Native() != obj.Native() {
+					t.Error("Expected", obj.Native(), "got", got.Native())
+				}
+				return
+			}
+			if got != tC.expected {
+				t.Error("Expected", tC.expected, "got", got)
+			}
+		})
+	}
+}
+
+func TestValueGetterTypeMismatch(t *testing.T) {
+	v, err := glib.NewValue("foo")
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+
+	if _, err := v.GetInt(); err == nil {
+		t.Error("Expected error getting an int from a string value")
+	}
+	if _, err := v.GetObject(); err == nil {
+		t.Error("Expected error getting an object from a string value")
+	}
+	if _, err := v.GetBoxed(); err == nil {
+		t.Error("Expected error getting a boxed value from a string value")
+	}
+}
+
+func TestGetSetProperty(t *testing.T) {
+	obj := newTestObject()
+
+	testCases := []struct {
+		desc  string
+		name  string
+		value interface{}
+	}{
+		{desc: "string", name: "string", value: "foo"},
+		{desc: "int", name: "int", value: 42},
+		{desc: "boolean", name: "boolean", value: true},
+		{desc: "enum from int", name: "mode", value: testobject.ModeSlow},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			if err := obj.SetProperty(tC.name, tC.value); err != nil {
+				t.Fatal("Unexpected error:", err)
+			}
+
+			value, err := obj.GetProperty(tC.name)
+			if err != nil {
+				t.Fatal("Unexpected error:", err)
+			}
+			if value != tC.value {
+				t.Error("Expected", tC.value, "got", value)
+			}
+		})
+	}
+
+	t.Run("object", func(t *testing.T) {
+		node := glib.ObjectNew(nodeType)
+		next := newTestObject()
+
+		if err := node.SetProperty("next", next); err != nil {
+			t.Fatal("Unexpected error:", err)
+		}
+
+		value, err := node.GetProperty("next")
+		if err != nil {
+			t.Fatal("Unexpected error:", err)
+		}
+		got, ok := value.(*glib.Object)
+		if !ok || got.Native() != next.Native() {
+			t.Error("Expected", next, "got", value)
+		}
+
+		if err := node.SetProperty("next", nil); err != nil {
+			t.Fatal("Unexpected error:", err)
+		}
+		if value, _ := node.GetProperty("next"); value != nil && value.(*glib.Object) != nil {
+			t.Error("Expected no object, got", value)
+		}
+	})
+
+	if err := obj.SetProperty("missing", 1); err == nil {
+		t.Error("Expected error for missing property")
+	}
+	if _, err := obj.GetProperty("missing"); err == nil {
+		t.Error("Expected error for missing property")
+	}
+}
+
+func TestValueRawGetters(t *testing.T) {
+	t.Run("boxed", func(t *testing.T) {
+		v, err := glib.ValueInit(glib.Type(testobject.StrvType()))
+		if err != nil {
+			t.Fatal("Unexpected error:", err)
+		}
+
+		strv := testobject.NewStrv("a,b")
+		v.SetBoxed(strv)
+		// The value holds its own copy.
+		testobject.FreeStrv(strv)
+
+		boxed, err := v.GetBoxed()
+		if err != nil {
+			t.Fatal("Unexpected error:", err)
+		}
+		if s := testobject.JoinStrv(boxed); s != "a,b" {
+			t.Error("Expected", "a,b", "got", s)
+		}
+	})
+
+	t.Run("pointer", func(t *testing.T) {
+		v, err := glib.ValueInit(glib.TYPE_POINTER)
+		if err != nil {
+			t.Fatal("Unexpected error:", err)
+		}
+
+		var x int
+		v.SetPointer(uintptr(unsafe.Pointer(&x)))
+
+		if p := v.GetPointer(); p != unsafe.Pointer(&x) {
+			t.Error("Expected", unsafe.Pointer(&x), "got", p)
+		}
+	})
+
+	t.Run("object", func(t *testing.T) {
+		obj := newTestObject()
+
+		v, err := glib.NewValue(obj)
+		if err != nil {
+			t.Fatal("Unexpected error:", err)
+		}
+
+		got, err := v.GetObject()
+		if err != nil {
+			t.Fatal("Unexpected error:", err)
+		}
+		if got.Native() != obj.Native() {
+			t.Error("Expected", obj.Native(), "got", got.Native())
+		}
+	})
+}
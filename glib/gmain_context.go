@@ -6,6 +6,8 @@ package glib
 // #include "glib.go.h"
 import "C"
 
+import "sync"
+
 type MainContext C.GMainContext
 
 // native returns a pointer to the underlying GMainContext.
@@ -25,7 +27,43 @@ func MainContextDefault() *MainContext {
 	return (*MainContext)(c)
 }
 
-// Iteration is a wrapper around g_main_context_iteration()
+// NewMainContext is a wrapper around g_main_context_new(). The returned
+// context isn't garbage collected, so Unref must be called once it's no longer
+// used.
+func NewMainContext() *MainContext {
+	return (*MainContext)(C.g_main_context_new())
+}
+
+// MainContextRefThreadDefault is a wrapper around
+// g_main_context_ref_thread_default(). It returns the context pushed as the
+// thread-default context of the calling thread, or the global default context
+// if there is none. A reference is taken, so Unref must be called once the
+// context is no longer used.
+func MainContextRefThreadDefault() *MainContext {
+	c := C.g_main_context_ref_thread_default()
+	if c == nil {
+		return nil
+	}
+	return (*MainContext)(c)
+}
+
+// Ref is a wrapper around g_main_context_ref().
+func (v *MainContext) Ref() *MainContext {
+	c := C.g_main_context_ref(v.native())
+	if c == nil {
+		return nil
+	}
+	return (*MainContext)(c)
+}
+
+// Unref is a wrapper around g_main_context_unref().
+func (v *MainContext) Unref() {
+	C.g_main_context_unref(v.native())
+}
+
+// Iteration is a wrapper around g_main_context_iteration(). It returns true if
+// any events were dispatched. Like MainLoop.Run, it should be called from a
+// goroutine locked to its thread if mayBlock is true.
 func (v *MainContext) Iteration(mayBlock bool) bool {
 	return gobool(C.g_main_context_iteration(v.native(), gbool(mayBlock)))
 }
@@ -49,12 +87,15 @@ func (v *MainContext) FindSourceById(hdlSrc SourceHandle) *Source {
 	return (*Source)(c)
 }
 
-// Acquire is a wrapper around g_main_context_acquire().
+// Acquire is a wrapper around g_main_context_acquire(). Ownership belongs to
+// the calling thread, so the goroutine must be locked to its thread using
+// runtime.LockOSThread until Release is called.
 func (v *MainContext) Acquire() bool {
 	return gobool(C.g_main_context_acquire(v.native()))
 }
 
-// Release is a wrapper around g_main_context_release().
+// Release is a wrapper around g_main_context_release(). It must be called from
+// the thread that acquired the context.
 func (v *MainContext) Release() {
 	C.g_main_context_release(v.native())
 }
@@ -63,3 +104,180 @@ func (v *MainContext) Release() {
 func (v *MainContext) IsOwner() bool {
 	return gobool(C.g_main_context_is_owner(v.native()))
 }
+
+// sourceRegistry counts the idle and timeout sources added by this package by
+// the context that they're attached to, and keeps track of which of them are
+// still alive.
+var sourceRegistry = struct {
+	sync.Mutex
+	contexts map[uintptr]*C.GMainContext // callback ID -> context
+	handles  map[SourceHandle]uintptr    // source ID -> callback ID
+	ids      map[uintptr]SourceHandle    // callback ID -> source ID
+	counts   map[*C.GMainContext]int
+}{
+	contexts: make(map[uintptr]*C.GMainContext),
+	handles:  make(map[SourceHandle]uintptr),
+	ids:      make(map[uintptr]SourceHandle),
+	counts:   make(map[*C.GMainContext]int),
+}
+
+// registerSource records that the source with the given callback ID is
+// attached to context. It must be called before attaching the source, since
+// the source may be destroyed right after.
+func registerSource(id uintptr, context *C.GMainContext) {
+	sourceRegistry.Lock()
+	sourceRegistry.contexts[id] = context
+	sourceRegistry.counts[context]++
+	sourceRegistry.Unlock()
+}
+
+// registerSourceHandle records the source ID of the source with the given
+// callback ID once it's attached. Nothing is recorded if the source has already
+// been destroyed.
+func registerSourceHandle(id uintptr, handle SourceHandle) {
+	sourceRegistry.Lock()
+	defer sourceRegistry.Unlock()
+
+	if _, ok := sourceRegistry.contexts[id]; ok {
+		sourceRegistry.handles[handle] = id
+		sourceRegistry.ids[id] = handle
+	}
+}
+
+// unregisterSource forgets the source with the given callback ID once it's
+// destroyed.
+func unregisterSource(id uintptr) {
+	sourceRegistry.Lock()
+	defer sourceRegistry.Unlock()
+
+	context, ok := sourceRegistry.contexts[id]
+	if !ok {
+		return
+	}
+
+	delete(sourceRegistry.contexts, id)
+	if sourceRegistry.counts[context]--; sourceRegistry.counts[context] == 0 {
+		delete(sourceRegistry.counts, context)
+	}
+
+	if handle, ok := sourceRegistry.ids[id]; ok {
+		delete(sourceRegistry.ids, id)
+		delete(sourceRegistry.handles, handle)
+	}
+}
+
+// sourceHandleContext returns the context of the source with the given ID if
+// it was added by this package and hasn't been destroyed yet, or nil otherwise.
+func sourceHandleContext(handle SourceHandle) *C.GMainContext {
+	sourceRegistry.Lock()
+	defer sourceRegistry.Unlock()
+
+	id, ok := sourceRegistry.handles[handle]
+	if !ok {
+		return nil
+	}
+	return sourceRegistry.contexts[id]
+}
+
+// defaultContext is the context that IdleAdd, TimeoutAdd and their variants
+// attach their sources to. Nil means the global default context.
+var defaultContext struct {
+	sync.Mutex
+	context *C.GMainContext
+}
+
+// SetDefaultContext makes IdleAdd, TimeoutAdd and their variants attach their
+// sources to context instead of the global default context, such as for a
+// library running its main loop on a context of its own. A reference is kept
+// on context until it's replaced. Passing nil restores the global default
+// context.
+func SetDefaultContext(context *MainContext) {
+	if context != nil {
+		C.g_main_context_ref(context.native())
+	}
+
+	defaultContext.Lock()
+	old := defaultContext.context
+	defaultContext.context = context.native()
+	defaultContext.Unlock()
+
+	if old != nil {
+		C.g_main_context_unref(old)
+	}
+}
+
+// sourceContext returns a new reference to the context that sources should be
+// attached to, as set by SetDefaultContext.
+func sourceContext() *C.GMainContext {
+	defaultContext.Lock()
+	defer defaultContext.Unlock()
+
+	if defaultContext.context != nil {
+		return C.g_main_context_ref(defaultContext.context)
+	}
+	return C.g_main_context_ref(C.g_main_context_default())
+}
+
+// SourceCount returns the number of idle and timeout sources added by this
+// package, such as by IdleAdd and TimeoutAdd, that are attached to the
+// context and not yet destroyed. Sources added from C are not counted. It's
+// meant for diagnosing runaway source creation.
+func (v *MainContext) SourceCount() int {
+	sourceRegistry.Lock()
+	defer sourceRegistry.Unlock()
+
+	return sourceRegistry.counts[v.native()]
+}
+
+// MainThreadOnce returns a function that calls f exactly once, on the thread
+// that owns the default main context, which is the thread running the main
+// loop. The default main context is the one set by SetDefaultContext, if any.
+// The returned function may be called from any goroutine: if it's not called
+// from the main loop, f is invoked from an idle source and the call blocks
+// until f returns, so the main loop must be running.
+//
+// Unlike sync.Once, calling the returned function from f itself returns
+// immediately instead of deadlocking.
+//
+// MainThreadOnce returns a function rather than calling f itself, since funcs
+// can't be compared, so it couldn't tell whether it was already given the same
+// f. Keep the returned function around like a sync.Once, such as in a
+// package-level variable, and call it wherever f must have run.
+func MainThreadOnce(f func()) func() {
+	var (
+		mu        sync.Mutex
+		scheduled bool
+		// done is only accessed from the main loop.
+		done   bool
+		doneCh = make(chan struct{})
+	)
+
+	run := func() {
+		if done {
+			return
+		}
+		done = true
+		defer close(doneCh)
+		f()
+	}
+
+	return func() {
+		context := sourceContext()
+		isOwner := gobool(C.g_main_context_is_owner(context))
+		C.g_main_context_unref(context)
+
+		if isOwner {
+			run()
+			return
+		}
+
+		mu.Lock()
+		if !scheduled {
+			scheduled = true
+			IdleAdd(run)
+		}
+		mu.Unlock()
+
+		<-doneCh
+	}
+}
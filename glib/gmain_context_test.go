@@ -0,0 +1,191 @@
+// Same copyright and license as the rest of the files in this project
+
+package glib_test
+
+import (
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/diamondburned/go-glib/glib"
+)
+
+// acquireOnThread tries to acquire ctx from a new goroutine locked to its own
+// thread, releasing it right after if successful.
+func acquireOnThread(ctx *glib.MainContext) bool {
+	acquired := make(chan bool)
+
+	go func() {
+		runtime.LockOSThread()
+		defer runtime.UnlockOSThread()
+
+		ok := ctx.Acquire()
+		if ok {
+			ctx.Release()
+		}
+
+		acquired <- ok
+	}()
+
+	return <-acquired
+}
+
+func TestMainContextAcquire(t *testing.T) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	ctx := glib.MainContextRefThreadDefault()
+	defer ctx.Unref()
+
+	if ctx != glib.MainContextDefault() {
+		t.Error("Expected the global default context without a thread-default one")
+	}
+
+	if !ctx.Acquire() {
+		t.Fatal("Failed to acquire context")
+	}
+	if !ctx.IsOwner() {
+		t.Error("Expected to own the context after acquiring it")
+	}
+
+	if acquireOnThread(ctx) {
+		t.Error("Expected another thread to fail to acquire the context")
+	}
+
+	ctx.Release()
+
+	if !acquireOnThread(ctx) {
+		t.Error("Expected another thread to acquire the released context")
+	}
+}
+
+func TestMainThreadOnce(t *testing.T) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	ctx := glib.MainContextDefault()
+	if !ctx.Acquire() {
+		t.Fatal("Failed to acquire context")
+	}
+	defer ctx.Release()
+
+	var calls int
+	var onMain bool
+
+	once := glib.MainThreadOnce(func() {
+		calls++
+		onMain = ctx.IsOwner()
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			once()
+		}()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	// Act as the main loop until all goroutines return.
+	for waiting := true; waiting; {
+		select {
+		case <-done:
+			waiting = false
+		default:
+			if !ctx.Iteration(false) {
+				time.Sleep(time.Millisecond)
+			}
+		}
+	}
+
+	// Calling it again, from the main loop this time, must not run f.
+	once()
+
+	if calls != 1 {
+		t.Error("Expected", 1, "call, got", calls)
+	}
+	if !onMain {
+		t.Error("Expected f to run on the main thread")
+	}
+}
+
+func TestMainContextSourceCount(t *testing.T) {
+	ctx := glib.MainContextDefault()
+	for ctx.Pending() {
+		ctx.Iteration(false)
+	}
+
+	base := ctx.SourceCount()
+	expectCount := func(expected int) {
+		t.Helper()
+		if count := ctx.SourceCount(); count != base+expected {
+			t.Error("Expected", base+expected, "sources, got", count)
+		}
+	}
+
+	idle := glib.IdleAdd(func() {})
+	expectCount(1)
+
+	timeout := glib.TimeoutAdd(60*60*1000, func() {})
+	expectCount(2)
+
+	glib.SourceRemove(idle)
+	expectCount(1)
+
+	glib.SourceRemove(timeout)
+	expectCount(0)
+
+	// Sources that are done running are destroyed as well.
+	glib.IdleAdd(func() {})
+	expectCount(1)
+
+	for ctx.Pending() {
+		ctx.Iteration(false)
+	}
+	expectCount(0)
+}
+
+func TestSetDefaultContext(t *testing.T) {
+	ctx := glib.NewMainContext()
+	defer ctx.Unref()
+
+	glib.SetDefaultContext(ctx)
+	defer glib.SetDefaultContext(nil)
+
+	var ran bool
+	glib.IdleAdd(func() { ran = true })
+
+	if count := ctx.SourceCount(); count != 1 {
+		t.Error("Expected", 1, "source on the custom context, got", count)
+	}
+
+	def := glib.MainContextDefault()
+	for def.Pending() {
+		def.Iteration(false)
+	}
+	if ran {
+		t.Fatal("Expected the idle not to run on the global default context")
+	}
+
+	for ctx.Pending() {
+		ctx.Iteration(false)
+	}
+	if !ran {
+		t.Error("Expected the idle to run on the custom context")
+	}
+
+	timeout := glib.TimeoutAdd(60*60*1000, func() {})
+	if !timeout.Remove() {
+		t.Error("Expected the timeout to be removed from the custom context")
+	}
+	if count := ctx.SourceCount(); count != 0 {
+		t.Error("Expected", 0, "sources on the custom context, got", count)
+	}
+}
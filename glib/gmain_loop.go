@@ -0,0 +1,110 @@
+package glib
+
+// #include <glib.h>
+// #include "glib.go.h"
+import "C"
+
+import (
+	"errors"
+	"os"
+	"os/signal"
+	"runtime"
+	"syscall"
+)
+
+// MainLoop is a representation of GLib's GMainLoop.
+type MainLoop struct {
+	loop *C.GMainLoop
+}
+
+// native returns a pointer to the underlying GMainLoop.
+func (v *MainLoop) native() *C.GMainLoop {
+	if v == nil {
+		return nil
+	}
+	return v.loop
+}
+
+// NewMainLoop is a wrapper around g_main_loop_new(). If ctx is nil, then the
+// default main context is used. A finalizer is set to unreference the loop.
+func NewMainLoop(ctx *MainContext, isRunning bool) *MainLoop {
+	c := C.g_main_loop_new(ctx.native(), gbool(isRunning))
+
+	loop := &MainLoop{c}
+	runtime.SetFinalizer(loop, (*MainLoop).unref)
+
+	return loop
+}
+
+func (v *MainLoop) unref() {
+	C.g_main_loop_unref(v.native())
+}
+
+// Run is a wrapper around g_main_loop_run(). It blocks until Quit is called.
+//
+// The loop acquires its context for the calling thread, so Run should be
+// called from a goroutine locked to its thread using runtime.LockOSThread;
+// otherwise, Go may move other goroutines onto the thread that owns the
+// context. Since Run is a cgo call, the Go scheduler keeps running other
+// goroutines on other threads while it blocks.
+func (v *MainLoop) Run() {
+	C.g_main_loop_run(v.native())
+}
+
+// Quit is a wrapper around g_main_loop_quit(). It is safe to call from any
+// goroutine.
+func (v *MainLoop) Quit() {
+	C.g_main_loop_quit(v.native())
+}
+
+// IsRunning is a wrapper around g_main_loop_is_running().
+func (v *MainLoop) IsRunning() bool {
+	return gobool(C.g_main_loop_is_running(v.native()))
+}
+
+// RunMainLoopUntilSignal runs a new main loop on the default main context
+// until one of the given OS signals is received, after which the loop is quit
+// and the function returns. If no signals are given, then os.Interrupt and
+// syscall.SIGTERM are used. The signals are handled as soon as the default main
+// context is owned by the loop's thread.
+//
+// An error is returned if the default main context is already owned by another
+// thread, since the loop would not be able to run.
+func RunMainLoopUntilSignal(signals ...os.Signal) error {
+	if len(signals) == 0 {
+		signals = []os.Signal{os.Interrupt, syscall.SIGTERM}
+	}
+
+	// The context is acquired by the current thread, so the goroutine must not
+	// be moved to another thread until the loop is done.
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, signals...)
+	defer signal.Stop(sigCh)
+
+	ctx := MainContextDefault()
+	if !ctx.Acquire() {
+		return errors.New("default main context is owned by another thread")
+	}
+	defer ctx.Release()
+
+	loop := NewMainLoop(ctx, false)
+
+	done := make(chan struct{})
+	defer close(done)
+
+	go func() {
+		select {
+		case <-sigCh:
+			// Quitting is thread-safe and wakes up the loop's context, unlike
+			// IdleAdd, which may attach to another one.
+			loop.Quit()
+		case <-done:
+		}
+	}()
+
+	loop.Run()
+	return nil
+}
@@ -0,0 +1,123 @@
+// Same copyright and license as the rest of the files in this project
+
+package glib_test
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/diamondburned/go-glib/glib"
+)
+
+func TestRunMainLoopUntilSignal(t *testing.T) {
+	process, err := os.FindProcess(os.Getpid())
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+
+	// Send the signal from within the loop, so that we know the signal handler
+	// is already installed.
+	glib.IdleAdd(func() {
+		if err := process.Signal(os.Interrupt); err != nil {
+			t.Error("Unexpected error:", err)
+		}
+	})
+
+	done := make(chan error, 1)
+	go func() { done <- glib.RunMainLoopUntilSignal(os.Interrupt) }()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Error("Unexpected error:", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("main loop did not quit after the signal")
+	}
+}
+
+func TestRunMainLoopUntilSignalDefaultContext(t *testing.T) {
+	ctx := glib.NewMainContext()
+	defer ctx.Unref()
+
+	// The loop runs on the global default context, regardless of the one
+	// that IdleAdd uses.
+	glib.SetDefaultContext(ctx)
+	defer glib.SetDefaultContext(nil)
+
+	process, err := os.FindProcess(os.Getpid())
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- glib.RunMainLoopUntilSignal(os.Interrupt) }()
+
+	// The signal handler is installed once the loop owns the global default
+	// context, which can then no longer be acquired here. Acquiring and
+	// releasing must happen on the same thread.
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	def := glib.MainContextDefault()
+	deadline := time.Now().Add(5 * time.Second)
+	for def.Acquire() {
+		def.Release()
+		if time.Now().After(deadline) {
+			t.Fatal("main loop did not start")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	if err := process.Signal(os.Interrupt); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Error("Unexpected error:", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("main loop did not quit after the signal")
+	}
+}
+
+func ExampleMainLoop() {
+	done := make(chan struct{})
+
+	go func() {
+		// The loop owns its context for as long as it runs, so keep it on a
+		// dedicated thread.
+		runtime.LockOSThread()
+		defer runtime.UnlockOSThread()
+
+		loop := glib.NewMainLoop(nil, false)
+		glib.IdleAdd(loop.Quit)
+		loop.Run()
+
+		close(done)
+	}()
+
+	<-done
+	fmt.Println("loop returned")
+	// Output: loop returned
+}
+
+func TestNewMainContext(t *testing.T) {
+	ctx := glib.NewMainContext()
+	defer ctx.Unref()
+
+	if ctx == glib.MainContextDefault() {
+		t.Error("Expected a context other than the default one")
+	}
+	if ctx.Pending() {
+		t.Error("Expected no pending events in a new context")
+	}
+	if ctx.Iteration(false) {
+		t.Error("Expected no events to be dispatched in a new context")
+	}
+}
@@ -0,0 +1,191 @@
+package glib
+
+// #include <glib.h>
+// #include <glib-object.h>
+// #include "glib.go.h"
+import "C"
+
+import (
+	"runtime"
+	"unsafe"
+)
+
+/*
+ * GParamSpec
+ */
+
+// ParamFlags is a representation of GLib's GParamFlags.
+type ParamFlags int
+
+const (
+	PARAM_READABLE        ParamFlags = C.G_PARAM_READABLE
+	PARAM_WRITABLE        ParamFlags = C.G_PARAM_WRITABLE
+	PARAM_READWRITE       ParamFlags = C.G_PARAM_READWRITE
+	PARAM_CONSTRUCT       ParamFlags = C.G_PARAM_CONSTRUCT
+	PARAM_CONSTRUCT_ONLY  ParamFlags = C.G_PARAM_CONSTRUCT_ONLY
+	PARAM_LAX_VALIDATION  ParamFlags = C.G_PARAM_LAX_VALIDATION
+	PARAM_STATIC_NAME     ParamFlags = C.G_PARAM_STATIC_NAME
+	PARAM_STATIC_NICK     ParamFlags = C.G_PARAM_STATIC_NICK
+	PARAM_STATIC_BLURB    ParamFlags = C.G_PARAM_STATIC_BLURB
+	PARAM_EXPLICIT_NOTIFY ParamFlags = C.G_PARAM_EXPLICIT_NOTIFY
+	PARAM_DEPRECATED      ParamFlags = C.G_PARAM_DEPRECATED
+)
+
+// paramStaticStrings are the flags that would make GLib keep the strings given
+// to it. They're never passed down, since the C strings made for the
+// constructors are freed right after.
+const paramStaticStrings = PARAM_STATIC_NAME | PARAM_STATIC_NICK | PARAM_STATIC_BLURB
+
+// ParamSpec is a representation of GLib's GParamSpec.
+type ParamSpec struct {
+	GParamSpec *C.GParamSpec
+}
+
+// native returns a pointer to the underlying GParamSpec.
+func (v *ParamSpec) native() *C.GParamSpec {
+	if v == nil {
+		return nil
+	}
+	return v.GParamSpec
+}
+
+// Native returns a pointer to the underlying GParamSpec.
+func (v *ParamSpec) Native() uintptr {
+	return uintptr(unsafe.Pointer(v.native()))
+}
+
+// takeParamSpec wraps the given pointer, sinking its floating reference or
+// taking a new one. The reference is released when the ParamSpec is garbage
+// collected.
+func takeParamSpec(c *C.GParamSpec) *ParamSpec {
+	if c == nil {
+		return nil
+	}
+
+	C.g_param_spec_ref_sink(c)
+
+	v := &ParamSpec{c}
+	runtime.SetFinalizer(v, func(v *ParamSpec) { C.g_param_spec_unref(v.native()) })
+
+	return v
+}
+
+// Name is a wrapper around g_param_spec_get_name().
+func (v *ParamSpec) Name() string {
+	return C.GoString((*C.char)(C.g_param_spec_get_name(v.native())))
+}
+
+// Nick is a wrapper around g_param_spec_get_nick().
+func (v *ParamSpec) Nick() string {
+	return C.GoString((*C.char)(C.g_param_spec_get_nick(v.native())))
+}
+
+// Blurb is a wrapper around g_param_spec_get_blurb().
+func (v *ParamSpec) Blurb() string {
+	return C.GoString((*C.char)(C.g_param_spec_get_blurb(v.native())))
+}
+
+// ValueType returns the type of the values of the property.
+func (v *ParamSpec) ValueType() Type {
+	return Type(v.native().value_type)
+}
+
+// Flags returns the flags of the property.
+func (v *ParamSpec) Flags() ParamFlags {
+	return ParamFlags(v.native().flags)
+}
+
+// OwnerType returns the type that installed the property, which is the
+// interface for properties installed by interfaces. It is TYPE_INVALID for
+// specs that are not installed yet.
+func (v *ParamSpec) OwnerType() Type {
+	return Type(v.native().owner_type)
+}
+
+// IsReadable returns true if the property can be read.
+func (v *ParamSpec) IsReadable() bool {
+	return v.Flags()&PARAM_READABLE != 0
+}
+
+// IsWritable returns true if the property can be written, either at any time
+// or only during construction.
+func (v *ParamSpec) IsWritable() bool {
+	return v.Flags()&PARAM_WRITABLE != 0
+}
+
+// IsConstructOnly returns true if the property can only be set during
+// construction.
+func (v *ParamSpec) IsConstructOnly() bool {
+	return v.Flags()&PARAM_CONSTRUCT_ONLY != 0
+}
+
+// paramSpecStrings returns C copies of the strings given to the ParamSpec
+// constructors. The returned function frees them.
+func paramSpecStrings(name, nick, blurb string) (cname, cnick, cblurb *C.gchar, free func()) {
+	cname = (*C.gchar)(C.CString(name))
+	cnick = (*C.gchar)(C.CString(nick))
+	cblurb = (*C.gchar)(C.CString(blurb))
+
+	return cname, cnick, cblurb, func() {
+		C.free(unsafe.Pointer(cname))
+		C.free(unsafe.Pointer(cnick))
+		C.free(unsafe.Pointer(cblurb))
+	}
+}
+
+// ParamSpecBoolean is a wrapper around g_param_spec_boolean().
+func ParamSpecBoolean(name, nick, blurb string, def bool, flags ParamFlags) *ParamSpec {
+	cname, cnick, cblurb, free := paramSpecStrings(name, nick, blurb)
+	defer free()
+
+	c := C.g_param_spec_boolean(cname, cnick, cblurb, gbool(def), C.GParamFlags(flags&^paramStaticStrings))
+	return takeParamSpec(c)
+}
+
+// ParamSpecInt is a wrapper around g_param_spec_int().
+func ParamSpecInt(name, nick, blurb string, min, max, def int, flags ParamFlags) *ParamSpec {
+	cname, cnick, cblurb, free := paramSpecStrings(name, nick, blurb)
+	defer free()
+
+	c := C.g_param_spec_int(
+		cname, cnick, cblurb,
+		C.gint(min), C.gint(max), C.gint(def),
+		C.GParamFlags(flags&^paramStaticStrings),
+	)
+	return takeParamSpec(c)
+}
+
+// ParamSpecDouble is a wrapper around g_param_spec_double().
+func ParamSpecDouble(name, nick, blurb string, min, max, def float64, flags ParamFlags) *ParamSpec {
+	cname, cnick, cblurb, free := paramSpecStrings(name, nick, blurb)
+	defer free()
+
+	c := C.g_param_spec_double(
+		cname, cnick, cblurb,
+		C.gdouble(min), C.gdouble(max), C.gdouble(def),
+		C.GParamFlags(flags&^paramStaticStrings),
+	)
+	return takeParamSpec(c)
+}
+
+// ParamSpecString is a wrapper around g_param_spec_string().
+func ParamSpecString(name, nick, blurb string, def string, flags ParamFlags) *ParamSpec {
+	cname, cnick, cblurb, free := paramSpecStrings(name, nick, blurb)
+	defer free()
+
+	cdef := (*C.gchar)(C.CString(def))
+	defer C.free(unsafe.Pointer(cdef))
+
+	c := C.g_param_spec_string(cname, cnick, cblurb, cdef, C.GParamFlags(flags&^paramStaticStrings))
+	return takeParamSpec(c)
+}
+
+// ParamSpecObject is a wrapper around g_param_spec_object(). objectType is the
+// type of the objects the property holds, which must be a GObject type.
+func ParamSpecObject(name, nick, blurb string, objectType Type, flags ParamFlags) *ParamSpec {
+	cname, cnick, cblurb, free := paramSpecStrings(name, nick, blurb)
+	defer free()
+
+	c := C.g_param_spec_object(cname, cnick, cblurb, C.GType(objectType), C.GParamFlags(flags&^paramStaticStrings))
+	return takeParamSpec(c)
+}
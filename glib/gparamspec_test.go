@@ -0,0 +1,70 @@
+// Same copyright and license as the rest of the files in this project
+
+package glib_test
+
+import (
+	"testing"
+
+	"github.com/diamondburned/go-glib/glib"
+	"github.com/diamondburned/go-glib/glib/internal/testobject"
+)
+
+func TestParamSpecFlags(t *testing.T) {
+	source := newTestObject()
+	bindings := source.BindProperties(newTestObject(), map[string]string{"int": "int"}, glib.BINDING_DEFAULT)
+	if len(bindings) != 1 {
+		t.Fatal("Expected", 1, "binding, got", len(bindings))
+	}
+	binding := bindings[0]
+	defer binding.Unbind()
+
+	testCases := []struct {
+		desc          string
+		obj           *glib.Object
+		name          string
+		ownerType     glib.Type
+		readable      bool
+		writable      bool
+		constructOnly bool
+	}{
+		{
+			desc:      "read-write",
+			obj:       source,
+			name:      "int",
+			ownerType: glib.Type(testobject.Type()),
+			readable:  true,
+			writable:  true,
+		},
+		{
+			desc:          "construct-only",
+			obj:           binding.Object,
+			name:          "source",
+			ownerType:     binding.TypeFromInstance(),
+			readable:      true,
+			writable:      true,
+			constructOnly: true,
+		},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			pspec := tC.obj.FindProperty(tC.name)
+			if pspec == nil {
+				t.Fatal("Failed to find property", tC.name)
+			}
+
+			if pspec.OwnerType() != tC.ownerType {
+				t.Error("Expected owner", tC.ownerType.Name(), "got", pspec.OwnerType().Name())
+			}
+			if pspec.IsReadable() != tC.readable {
+				t.Error("Expected readable", tC.readable, "got", pspec.IsReadable())
+			}
+			if pspec.IsWritable() != tC.writable {
+				t.Error("Expected writable", tC.writable, "got", pspec.IsWritable())
+			}
+			if pspec.IsConstructOnly() != tC.constructOnly {
+				t.Error("Expected construct-only", tC.constructOnly, "got", pspec.IsConstructOnly())
+			}
+		})
+	}
+}
@@ -29,3 +29,24 @@ func MainCurrentSource() *Source {
 	}
 	return (*Source)(c)
 }
+
+// GetTime is a wrapper around g_source_get_time(). It returns the monotonic
+// time at which the main context of the source started its current iteration,
+// in microseconds. The source must be attached to a context.
+func (v *Source) GetTime() int64 {
+	return int64(C.g_source_get_time(v.native()))
+}
+
+// GetReadyTime is a wrapper around g_source_get_ready_time(). It returns the
+// monotonic time, in microseconds, at which the source will be dispatched, or
+// -1 if it's not scheduled for a time. For timeout sources, this tells how long
+// remains until the next firing when compared to GetMonotonicTime.
+func (v *Source) GetReadyTime() int64 {
+	return int64(C.g_source_get_ready_time(v.native()))
+}
+
+// GetMonotonicTime is a wrapper around g_get_monotonic_time(). It returns the
+// time of the monotonic clock used by main contexts, in microseconds.
+func GetMonotonicTime() int64 {
+	return int64(C.g_get_monotonic_time())
+}
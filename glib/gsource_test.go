@@ -0,0 +1,140 @@
+// Same copyright and license as the rest of the files in this project
+
+package glib_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/diamondburned/go-glib/glib"
+)
+
+func TestSourceTime(t *testing.T) {
+	ctx := glib.MainContextDefault()
+
+	var times []int64
+	before := glib.GetMonotonicTime()
+	handle := glib.TimeoutAdd(1, func() bool {
+		times = append(times, glib.MainCurrentSource().GetTime())
+		return len(times) < 3
+	})
+
+	source := ctx.FindSourceById(handle)
+	if source == nil {
+		t.Fatal("Failed to find the timeout source")
+	}
+	if ready := source.GetReadyTime(); ready < before {
+		t.Error("Expected the timeout to be ready after", before, "got", ready)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for len(times) < 3 && time.Now().Before(deadline) {
+		ctx.Iteration(true)
+	}
+
+	if len(times) != 3 {
+		t.Fatal("Expected", 3, "firings, got", len(times))
+	}
+	for i := 1; i < len(times); i++ {
+		if times[i] <= times[i-1] {
+			t.Error("Expected the dispatch times to advance, got", times)
+			break
+		}
+	}
+	if now := glib.GetMonotonicTime(); now < times[len(times)-1] {
+		t.Error("Expected", now, "to be after the last dispatch time", times[len(times)-1])
+	}
+}
+
+func TestIdleAddArgs(t *testing.T) {
+	ctx := glib.MainContextDefault()
+
+	const n = 1000
+	counts := make([]int, n)
+
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			glib.IdleAdd(func(i int) { counts[i]++ }, i)
+		}(i)
+	}
+	wg.Wait()
+
+	// Each callback removes itself after running once, so the context runs
+	// out of events once all of them have.
+	for ctx.Pending() {
+		ctx.Iteration(false)
+	}
+
+	for i, count := range counts {
+		if count != 1 {
+			t.Error("Expected callback", i, "to run once, got", count)
+		}
+	}
+}
+
+func TestTimeoutAddArgs(t *testing.T) {
+	ctx := glib.MainContextDefault()
+
+	var got []string
+	glib.TimeoutAdd(1, func(s string, n int) bool {
+		got = append(got, s)
+		return len(got) < n
+	}, "foo", 2)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for len(got) < 2 && time.Now().Before(deadline) {
+		ctx.Iteration(true)
+	}
+
+	if len(got) != 2 || got[0] != "foo" || got[1] != "foo" {
+		t.Error("Expected", []string{"foo", "foo"}, "got", got)
+	}
+}
+
+func TestIdleAddArgsMismatch(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic, did not get one")
+		}
+	}()
+
+	glib.IdleAdd(func(i int) {}, "foo")
+}
+
+func TestSourceHandleRemove(t *testing.T) {
+	ctx := glib.MainContextDefault()
+
+	var called bool
+	handle := glib.TimeoutAdd(10, func() { called = true })
+
+	if !handle.Remove() {
+		t.Fatal("Expected the pending timeout to be removed")
+	}
+	if handle.Remove() {
+		t.Error("Expected removing the timeout again to fail")
+	}
+
+	deadline := time.Now().Add(50 * time.Millisecond)
+	for time.Now().Before(deadline) {
+		if !ctx.Iteration(false) {
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	if called {
+		t.Error("Expected the removed timeout to never run")
+	}
+
+	// A source that removed itself after running can't be removed either.
+	idle := glib.IdleAdd(func() {})
+	for ctx.Pending() {
+		ctx.Iteration(false)
+	}
+	if glib.RemoveSource(idle) {
+		t.Error("Expected removing a finished idle source to fail")
+	}
+}
@@ -0,0 +1,637 @@
+package glib
+
+// #include <glib.h>
+// #include <glib-object.h>
+// #include "glib.go.h"
+// #include "gtype.go.h"
+import "C"
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"unsafe"
+
+	"github.com/diamondburned/go-glib/core/callback"
+)
+
+/*
+ * Type registration
+ */
+
+// registeredTypes holds the Go data of the types registered using
+// RegisterSubclass and RegisterInterface. Static types can never be
+// unregistered, so neither can their data.
+var registeredTypes = struct {
+	sync.RWMutex
+	subclasses map[Type]*subclass
+	interfaces map[Type]func(*InterfaceInfo)
+}{
+	subclasses: make(map[Type]*subclass),
+	interfaces: make(map[Type]func(*InterfaceInfo)),
+}
+
+// subclass holds the Go functions of a type registered using RegisterSubclass.
+type subclass struct {
+	classInit    func(*ObjectClass)
+	instanceInit func(*Object)
+	notify       func(*Object, *ParamSpec)
+	newData      func() interface{}
+	// properties are installed before classInit is called.
+	properties []subclassProperty
+}
+
+// subclassProperty is a property added using RegisterProperty.
+type subclassProperty struct {
+	spec *ParamSpec
+	get  PropertyGetter
+	set  PropertySetter
+}
+
+// registerTypeName registers a new type with the given name using register.
+// It panics if the name is already taken or if the registration fails.
+func registerTypeName(name string, register func(*C.gchar) C.GType) Type {
+	if TypeFromName(name) != TYPE_INVALID {
+		panic(fmt.Sprintf("glib: type %q is already registered", name))
+	}
+
+	cname := (*C.gchar)(C.CString(name))
+	defer C.free(unsafe.Pointer(cname))
+
+	t := Type(register(cname))
+	if t == TYPE_INVALID {
+		panic(fmt.Sprintf("glib: failed to register type %q", name))
+	}
+
+	return t
+}
+
+// RegisterSubclass registers a new type with the given name deriving from
+// parent, which must be a GObject type. classInit is called once when the class
+// of the new type is initialized, which happens when the first instance is
+// created; it may install the properties of the type. instanceInit is called
+// for every new instance. Either function may be nil.
+//
+// RegisterSubclass panics if parent is not a GObject type or if the name is
+// already taken. Types cannot be unregistered, so types should only be
+// registered once, usually on initialization.
+func RegisterSubclass(parent Type, name string, classInit func(klass *ObjectClass), instanceInit func(obj *Object)) Type {
+	if !parent.IsA(TYPE_OBJECT) {
+		panic(fmt.Sprintf("glib: cannot register %q: parent %s is not a GObject type", name, parent.Name()))
+	}
+
+	registeredTypes.Lock()
+	defer registeredTypes.Unlock()
+
+	t := registerTypeName(name, func(cname *C.gchar) C.GType {
+		return C._g_type_register_subclass(C.GType(parent), cname)
+	})
+
+	registeredTypes.subclasses[t] = &subclass{
+		classInit:    classInit,
+		instanceInit: instanceInit,
+	}
+
+	return t
+}
+
+// RegisterProperty adds a property to objType, which must have been registered
+// using RegisterSubclass, similarly to calling InstallProperty in its class
+// init function. The property is installed when the class is initialized,
+// before the class init function is called, so RegisterProperty must be called
+// before the first instance of objType is created.
+//
+// RegisterProperty panics if objType was not registered using RegisterSubclass
+// or if its class is already initialized.
+func RegisterProperty(objType Type, spec *ParamSpec, get PropertyGetter, set PropertySetter) {
+	registeredTypes.Lock()
+	defer registeredTypes.Unlock()
+
+	sub := registeredTypes.subclasses[objType]
+	if sub == nil {
+		panic(fmt.Sprintf("glib: cannot register property %q: %s was not registered from Go", spec.Name(), objType.Name()))
+	}
+	if C.g_type_class_peek(C.GType(objType)) != nil {
+		panic(fmt.Sprintf("glib: cannot register property %q: class %s is already initialized", spec.Name(), objType.Name()))
+	}
+
+	sub.properties = append(sub.properties, subclassProperty{spec, get, set})
+}
+
+// RegisterInstanceData makes every new instance of objType hold the value
+// returned by newData, which can be retrieved using Object.InstanceData. This
+// backs the type with a Go struct, such as one holding the fields of the
+// instance and implementing its methods. newData is called before the instance
+// init functions of any type, so they can already use the data.
+//
+// An instance only holds one value, so the data of a type deriving from objType
+// replaces that of objType, and it should embed the data of objType for the
+// code of objType to keep working. The data is released once the instance is
+// finalized; it must not reference the instance's Object, or the instance will
+// never be finalized. Use a WeakRef instead.
+//
+// RegisterInstanceData panics if objType was not registered using
+// RegisterSubclass or if its class is already initialized.
+func RegisterInstanceData(objType Type, newData func() interface{}) {
+	registeredTypes.Lock()
+	defer registeredTypes.Unlock()
+
+	sub := registeredTypes.subclasses[objType]
+	if sub == nil {
+		panic(fmt.Sprintf("glib: cannot register instance data: %s was not registered from Go", objType.Name()))
+	}
+	if C.g_type_class_peek(C.GType(objType)) != nil {
+		panic(fmt.Sprintf("glib: cannot register instance data: class %s is already initialized", objType.Name()))
+	}
+
+	sub.newData = newData
+}
+
+// InstanceData returns the value created for v by the function registered
+// using RegisterInstanceData for its type, or nil if there is none.
+func (v *Object) InstanceData() interface{} {
+	return getGoQdata(v.native(), C._go_instance_data_quark())
+}
+
+// setInstanceData creates the instance data of obj using the function of the
+// most derived of the given types that has one.
+func setInstanceData(obj *C.GObject, subclasses []*subclass) {
+	for i := len(subclasses) - 1; i >= 0; i-- {
+		if newData := subclasses[i].newData; newData != nil {
+			setGoQdata(obj, C._go_instance_data_quark(), newData())
+			return
+		}
+	}
+}
+
+// RegisterInterface registers a new interface type with the given name, which
+// requires GObject. init is called once when the interface is initialized,
+// which happens when the class of the first type implementing it is; it may
+// install the properties of the interface. init may be nil.
+//
+// RegisterInterface panics if the name is already taken.
+func RegisterInterface(name string, init func(iface *InterfaceInfo)) Type {
+	registeredTypes.Lock()
+	defer registeredTypes.Unlock()
+
+	t := registerTypeName(name, func(cname *C.gchar) C.GType {
+		return C._g_type_register_interface(cname)
+	})
+
+	registeredTypes.interfaces[t] = init
+	return t
+}
+
+// TypeAddInterfaceStatic is a wrapper around g_type_add_interface_static(). It
+// marks instanceType as implementing interfaceType, and it must be called
+// before the class of instanceType is initialized. Properties declared by the
+// interface must be overridden in the class init function of instanceType
+// using OverrideProperty.
+func TypeAddInterfaceStatic(instanceType, interfaceType Type) {
+	C._g_type_add_interface_static(C.GType(instanceType), C.GType(interfaceType))
+}
+
+//export goClassInit
+func goClassInit(gclass C.gpointer, classData C.gpointer) {
+	t := Type(C._g_type_from_class(gclass))
+
+	registeredTypes.RLock()
+	sub := registeredTypes.subclasses[t]
+	registeredTypes.RUnlock()
+
+	klass := &ObjectClass{(*C.GObjectClass)(unsafe.Pointer(gclass))}
+	C._go_object_class_init(klass.native())
+
+	if sub == nil {
+		return
+	}
+
+	for _, prop := range sub.properties {
+		klass.InstallProperty(prop.spec, prop.get, prop.set)
+	}
+
+	if sub.classInit != nil {
+		sub.classInit(klass)
+	}
+}
+
+// instanceInits tracks the instances that are being initialized. GLib calls
+// the instance init function of every type in the hierarchy of the instance,
+// from the root down, and since all types registered from Go share the same
+// one, the number of calls so far tells which type is being initialized.
+var instanceInits = struct {
+	sync.Mutex
+	progress map[unsafe.Pointer]int
+}{
+	progress: make(map[unsafe.Pointer]int),
+}
+
+//export goInstanceInit
+func goInstanceInit(instance *C.GTypeInstance, gclass C.gpointer) {
+	subclasses := subclassChain(Type(C._g_type_from_class(gclass)))
+	if len(subclasses) == 0 {
+		return
+	}
+
+	ptr := unsafe.Pointer(instance)
+
+	instanceInits.Lock()
+	n := instanceInits.progress[ptr]
+	if n+1 < len(subclasses) {
+		instanceInits.progress[ptr] = n + 1
+	} else {
+		delete(instanceInits.progress, ptr)
+	}
+	instanceInits.Unlock()
+
+	if n == 0 {
+		setInstanceData((*C.GObject)(ptr), subclasses)
+	}
+
+	if init := subclasses[n].instanceInit; init != nil {
+		init(Take(ptr))
+	}
+}
+
+// subclassChain returns the Go data of the types in the hierarchy of t that
+// were registered using RegisterSubclass, from the root down.
+func subclassChain(t Type) []*subclass {
+	registeredTypes.RLock()
+	defer registeredTypes.RUnlock()
+
+	var chain []*subclass
+	for ; t != TYPE_INVALID; t = t.Parent() {
+		if sub, ok := registeredTypes.subclasses[t]; ok {
+			chain = append([]*subclass{sub}, chain...)
+		}
+	}
+
+	return chain
+}
+
+//export goInterfaceInit
+func goInterfaceInit(giface C.gpointer, ifaceData C.gpointer) {
+	t := Type(C._g_type_from_interface(giface))
+
+	registeredTypes.RLock()
+	init := registeredTypes.interfaces[t]
+	registeredTypes.RUnlock()
+
+	if init != nil {
+		init(&InterfaceInfo{giface})
+	}
+}
+
+/*
+ * Simple objects
+ */
+
+// SimpleProperty declares a property of a type registered using
+// RegisterSimpleObject.
+type SimpleProperty struct {
+	// Type is the type of the property. It must be TYPE_BOOLEAN, TYPE_INT,
+	// TYPE_DOUBLE, TYPE_STRING or a GObject type.
+	Type Type
+	// Default is the default value of the property, which must be a bool, int,
+	// float64 or string matching Type. A nil Default is the zero value. It must
+	// be nil for object properties, which default to nil.
+	Default interface{}
+	// Flags are the flags of the property. PARAM_READWRITE is used if there
+	// are none.
+	Flags ParamFlags
+}
+
+// paramSpec creates the ParamSpec of the property with the given name. It
+// panics if the type or the default value is unsupported.
+func (p SimpleProperty) paramSpec(name string) *ParamSpec {
+	flags := p.Flags
+	if flags == 0 {
+		flags = PARAM_READWRITE
+	}
+
+	invalid := func() {
+		panic(fmt.Sprintf("glib: invalid default %#v for property %q of type %s", p.Default, name, p.Type.Name()))
+	}
+
+	switch {
+	case p.Type == TYPE_BOOLEAN:
+		def, ok := p.Default.(bool)
+		if !ok && p.Default != nil {
+			invalid()
+		}
+		return ParamSpecBoolean(name, name, name, def, flags)
+
+	case p.Type == TYPE_INT:
+		def, ok := p.Default.(int)
+		if !ok && p.Default != nil {
+			invalid()
+		}
+		return ParamSpecInt(name, name, name, math.MinInt32, math.MaxInt32, def, flags)
+
+	case p.Type == TYPE_DOUBLE:
+		def, ok := p.Default.(float64)
+		if !ok && p.Default != nil {
+			invalid()
+		}
+		return ParamSpecDouble(name, name, name, -math.MaxFloat64, math.MaxFloat64, def, flags)
+
+	case p.Type == TYPE_STRING:
+		def, ok := p.Default.(string)
+		if !ok && p.Default != nil {
+			invalid()
+		}
+		return ParamSpecString(name, name, name, def, flags)
+
+	case p.Type.IsA(TYPE_OBJECT):
+		if p.Default != nil {
+			invalid()
+		}
+		return ParamSpecObject(name, name, name, p.Type, flags)
+
+	default:
+		panic(fmt.Sprintf("glib: unsupported type %s for property %q", p.Type.Name(), name))
+	}
+}
+
+// simpleObjects holds the property values of the instances of the types
+// registered using RegisterSimpleObject, until they're finalized. The values
+// of properties that were never set are missing.
+var simpleObjects = struct {
+	sync.Mutex
+	values map[unsafe.Pointer]map[string]*Value
+}{
+	values: make(map[unsafe.Pointer]map[string]*Value),
+}
+
+// RegisterSimpleObject registers a new GObject subclass with the given name
+// and properties, whose values are simply stored by the instances. It is a
+// shortcut for RegisterSubclass for types that only hold values, such as the
+// items of a model.
+//
+// RegisterSimpleObject panics if a property is invalid or if the name is
+// already taken.
+func RegisterSimpleObject(name string, properties map[string]SimpleProperty) Type {
+	names := make([]string, 0, len(properties))
+	for propName := range properties {
+		names = append(names, propName)
+	}
+	sort.Strings(names)
+
+	specs := make([]*ParamSpec, len(names))
+	for i, propName := range names {
+		specs[i] = properties[propName].paramSpec(propName)
+	}
+
+	return RegisterSubclass(TYPE_OBJECT, name, func(klass *ObjectClass) {
+		for _, spec := range specs {
+			klass.InstallProperty(spec, simpleObjectGetter(spec), simpleObjectSetter(spec))
+		}
+	}, initSimpleObject)
+}
+
+func initSimpleObject(obj *Object) {
+	ptr := unsafe.Pointer(obj.native())
+
+	simpleObjects.Lock()
+	simpleObjects.values[ptr] = make(map[string]*Value)
+	simpleObjects.Unlock()
+
+	id := C.gpointer(callback.Assign(func() {
+		simpleObjects.Lock()
+		delete(simpleObjects.values, ptr)
+		simpleObjects.Unlock()
+	}))
+	C.g_object_weak_ref(obj.native(), (*[0]byte)(C.goWeakNotify), id)
+}
+
+func simpleObjectGetter(spec *ParamSpec) PropertyGetter {
+	name := spec.Name()
+
+	return func(obj *Object, value *Value) {
+		simpleObjects.Lock()
+		v := simpleObjects.values[unsafe.Pointer(obj.native())][name]
+		simpleObjects.Unlock()
+
+		if v == nil {
+			C.g_param_value_set_default(spec.native(), value.native())
+			return
+		}
+
+		C.g_value_copy(v.native(), value.native())
+	}
+}
+
+func simpleObjectSetter(spec *ParamSpec) PropertySetter {
+	name := spec.Name()
+
+	return func(obj *Object, value *Value) {
+		v, err := ValueInit(spec.ValueType())
+		if err != nil {
+			return
+		}
+		C.g_value_copy(value.native(), v.native())
+
+		simpleObjects.Lock()
+		if values := simpleObjects.values[unsafe.Pointer(obj.native())]; values != nil {
+			values[name] = v
+		}
+		simpleObjects.Unlock()
+	}
+}
+
+/*
+ * Properties
+ */
+
+// PropertyGetter is called to get the value of a property of obj. value is
+// initialized to the type of the property, and the getter must set it.
+type PropertyGetter func(obj *Object, value *Value)
+
+// PropertySetter is called to set a property of obj to value.
+type PropertySetter func(obj *Object, value *Value)
+
+type propertyHandler struct {
+	get PropertyGetter
+	set PropertySetter
+}
+
+// properties holds the handlers of the properties installed from Go. A
+// property's ID is its index plus one, which keeps the IDs unique across all
+// types, so the handlers can be found from the ID alone.
+var properties = struct {
+	sync.RWMutex
+	handlers []propertyHandler
+	// ids holds the IDs by the class type and name of the property, so that
+	// installing a property again when the class of a dynamic type is
+	// initialized again replaces its handler instead of adding another one.
+	ids map[propertyKey]C.guint
+}{
+	ids: make(map[propertyKey]C.guint),
+}
+
+type propertyKey struct {
+	t    Type
+	name string
+}
+
+func registerPropertyHandler(t Type, name string, get PropertyGetter, set PropertySetter) C.guint {
+	properties.Lock()
+	defer properties.Unlock()
+
+	key := propertyKey{t, name}
+	if id, ok := properties.ids[key]; ok {
+		properties.handlers[id-1] = propertyHandler{get, set}
+		return id
+	}
+
+	properties.handlers = append(properties.handlers, propertyHandler{get, set})
+	id := C.guint(len(properties.handlers))
+	properties.ids[key] = id
+	return id
+}
+
+func propertyHandlerFromID(id C.guint) propertyHandler {
+	properties.RLock()
+	defer properties.RUnlock()
+
+	if id == 0 || int(id) > len(properties.handlers) {
+		return propertyHandler{}
+	}
+	return properties.handlers[id-1]
+}
+
+//export goObjectSetProperty
+func goObjectSetProperty(gobject *C.GObject, id C.guint, value *C.GValue, pspec *C.GParamSpec) {
+	handler := propertyHandlerFromID(id)
+	if handler.set == nil {
+		C._g_object_warn_invalid_property_id(gobject, id, pspec)
+		return
+	}
+
+	handler.set(Take(unsafe.Pointer(gobject)), &Value{value})
+}
+
+//export goObjectGetProperty
+func goObjectGetProperty(gobject *C.GObject, id C.guint, value *C.GValue, pspec *C.GParamSpec) {
+	handler := propertyHandlerFromID(id)
+	if handler.get == nil {
+		C._g_object_warn_invalid_property_id(gobject, id, pspec)
+		return
+	}
+
+	handler.get(Take(unsafe.Pointer(gobject)), &Value{value})
+}
+
+/*
+ * GObjectClass
+ */
+
+// ObjectClass is a representation of GLib's GObjectClass. It is given to the
+// class init function of types registered using RegisterSubclass.
+type ObjectClass struct {
+	GObjectClass *C.GObjectClass
+}
+
+// native returns a pointer to the underlying GObjectClass.
+func (v *ObjectClass) native() *C.GObjectClass {
+	if v == nil {
+		return nil
+	}
+	return v.GObjectClass
+}
+
+// Type returns the type of the class.
+func (v *ObjectClass) Type() Type {
+	return Type(C._g_type_from_class(C.gpointer(v.native())))
+}
+
+// InstallProperty is a wrapper around g_object_class_install_property(). get
+// and set are called when the property is read or written; either may be nil
+// if the property is not readable or writable. Installing the property again,
+// as happens when the class of a dynamic type is initialized again, replaces
+// get and set.
+func (v *ObjectClass) InstallProperty(spec *ParamSpec, get PropertyGetter, set PropertySetter) {
+	id := registerPropertyHandler(v.Type(), spec.Name(), get, set)
+	C.g_object_class_install_property(v.native(), id, spec.native())
+}
+
+// OverrideProperty is a wrapper around g_object_class_override_property(). It
+// implements the property of the given name from an interface of the class or
+// overrides the one of its parent class, with get and set being called when
+// the property is read or written.
+func (v *ObjectClass) OverrideProperty(name string, get PropertyGetter, set PropertySetter) {
+	cname := (*C.gchar)(C.CString(name))
+	defer C.free(unsafe.Pointer(cname))
+
+	id := registerPropertyHandler(v.Type(), name, get, set)
+	C.g_object_class_override_property(v.native(), id, cname)
+}
+
+// OverrideNotify overrides the notify vfunc of the class, which is called
+// whenever a property of an instance changes, before the handlers connected to
+// the "notify" signal. f is called with the ParamSpec of the changed property,
+// after which the notify vfunc of the parent class is chained up to.
+//
+// OverrideNotify panics if the class is not of a type registered using
+// RegisterSubclass. It should only be called in the class init function.
+func (v *ObjectClass) OverrideNotify(f func(obj *Object, pspec *ParamSpec)) {
+	t := v.Type()
+
+	registeredTypes.Lock()
+	sub := registeredTypes.subclasses[t]
+	if sub != nil {
+		sub.notify = f
+	}
+	registeredTypes.Unlock()
+
+	if sub == nil {
+		panic(fmt.Sprintf("glib: cannot override notify of %s: not registered from Go", t.Name()))
+	}
+
+	C._go_object_class_override_notify(v.native())
+}
+
+//export goObjectNotify
+func goObjectNotify(gobject *C.GObject, pspec *C.GParamSpec) {
+	obj := Take(unsafe.Pointer(gobject))
+	spec := takeParamSpec(pspec)
+
+	// Every class overriding notify from Go shares goObjectNotify, so run the
+	// overrides of the hierarchy from the instance type up, until the first
+	// type not registered from Go, whose vfunc is then chained up to.
+	for t := obj.TypeFromInstance(); t != TYPE_INVALID; t = t.Parent() {
+		registeredTypes.RLock()
+		sub, ok := registeredTypes.subclasses[t]
+		var notify func(*Object, *ParamSpec)
+		if ok {
+			notify = sub.notify
+		}
+		registeredTypes.RUnlock()
+
+		if !ok {
+			C._g_object_class_notify(C.GType(t), gobject, pspec)
+			return
+		}
+
+		if notify != nil {
+			notify(obj, spec)
+		}
+	}
+}
+
+/*
+ * GTypeInterface
+ */
+
+// InterfaceInfo is given to the init function of interfaces registered using
+// RegisterInterface. It represents the default vtable of the interface.
+type InterfaceInfo struct {
+	iface C.gpointer
+}
+
+// InstallProperty is a wrapper around g_object_interface_install_property().
+// The property must be overridden by the classes implementing the interface.
+func (v *InterfaceInfo) InstallProperty(spec *ParamSpec) {
+	C.g_object_interface_install_property(v.iface, spec.native())
+}
@@ -0,0 +1,105 @@
+// Same copyright and license as the rest of the files in this project
+
+#ifndef __GTYPE_GO_H__
+#define __GTYPE_GO_H__
+
+#include <glib-object.h>
+#include <glib.h>
+#include <stdlib.h>
+
+extern void goClassInit(gpointer g_class, gpointer class_data);
+extern void goInstanceInit(GTypeInstance *instance, gpointer g_class);
+extern void goInterfaceInit(gpointer g_iface, gpointer iface_data);
+
+extern void goObjectSetProperty(GObject *object, guint property_id,
+                                GValue *value, GParamSpec *pspec);
+extern void goObjectGetProperty(GObject *object, guint property_id,
+                                GValue *value, GParamSpec *pspec);
+extern void goObjectNotify(GObject *object, GParamSpec *pspec);
+
+static GType _g_type_from_class(gpointer g_class) {
+  return (G_TYPE_FROM_CLASS(g_class));
+}
+
+static GType _g_type_from_interface(gpointer g_iface) {
+  return (G_TYPE_FROM_INTERFACE(g_iface));
+}
+
+static void _go_object_set_property(GObject *object, guint property_id,
+                                    const GValue *value, GParamSpec *pspec) {
+  goObjectSetProperty(object, property_id, (GValue *)value, pspec);
+}
+
+// Route the property vfuncs of a class registered from Go into Go.
+static void _go_object_class_init(GObjectClass *klass) {
+  klass->set_property = _go_object_set_property;
+  klass->get_property = goObjectGetProperty;
+}
+
+static void _go_object_class_override_notify(GObjectClass *klass) {
+  klass->notify = goObjectNotify;
+}
+
+// Call the notify vfunc of the class of type, unless it's the one of the
+// classes registered from Go, which chain up by themselves.
+static void _g_object_class_notify(GType type, GObject *object,
+                                   GParamSpec *pspec) {
+  GObjectClass *klass = g_type_class_peek(type);
+  if (klass != NULL && klass->notify != NULL &&
+      klass->notify != goObjectNotify) {
+    klass->notify(object, pspec);
+  }
+}
+
+static void _g_object_warn_invalid_property_id(GObject *object,
+                                               guint property_id,
+                                               GParamSpec *pspec) {
+  G_OBJECT_WARN_INVALID_PROPERTY_ID(object, property_id, pspec);
+}
+
+// Register a subclass of parent that has the same class and instance sizes.
+// G_TYPE_INVALID is returned if parent is not a valid type.
+static GType _g_type_register_subclass(GType parent, const gchar *name) {
+  GTypeQuery query;
+  GTypeInfo info = {0};
+
+  g_type_query(parent, &query);
+  if (query.type == G_TYPE_INVALID) {
+    return G_TYPE_INVALID;
+  }
+
+  info.class_size = query.class_size;
+  info.class_init = (GClassInitFunc)goClassInit;
+  info.instance_size = query.instance_size;
+  info.instance_init = (GInstanceInitFunc)goInstanceInit;
+
+  return g_type_register_static(parent, name, &info, 0);
+}
+
+static GQuark _go_instance_data_quark() {
+  return g_quark_from_static_string("go-glib-instance-data");
+}
+
+// Register an interface type that requires GObject.
+static GType _g_type_register_interface(const gchar *name) {
+  GTypeInfo info = {0};
+  GType type;
+
+  info.class_size = sizeof(GTypeInterface);
+  info.class_init = (GClassInitFunc)goInterfaceInit;
+
+  type = g_type_register_static(G_TYPE_INTERFACE, name, &info, 0);
+  if (type != G_TYPE_INVALID) {
+    g_type_interface_add_prerequisite(type, G_TYPE_OBJECT);
+  }
+
+  return type;
+}
+
+static void _g_type_add_interface_static(GType instance_type,
+                                         GType interface_type) {
+  GInterfaceInfo info = {NULL, NULL, NULL};
+  g_type_add_interface_static(instance_type, interface_type, &info);
+}
+
+#endif
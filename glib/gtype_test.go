@@ -0,0 +1,526 @@
+// Same copyright and license as the rest of the files in this project
+
+package glib_test
+
+import (
+	"testing"
+
+	"github.com/diamondburned/go-glib/glib"
+)
+
+// Types can only be registered once per process, so they are registered on
+// initialization rather than in the tests.
+var (
+	levelIfaceType = glib.RegisterInterface("GoGlibTestLevelIface", func(iface *glib.InterfaceInfo) {
+		iface.InstallProperty(glib.ParamSpecInt(
+			"level", "Level", "The level of the object",
+			0, 100, 10, glib.PARAM_READWRITE,
+		))
+	})
+
+	levels = map[uintptr]int{}
+
+	levelImplType = registerLevelImpl()
+
+	idIfaceType = glib.RegisterInterface("GoGlibTestIDIface", func(iface *glib.InterfaceInfo) {
+		iface.InstallProperty(glib.ParamSpecString(
+			"id", "ID", "The ID of the object, fixed on construction",
+			"", glib.PARAM_READWRITE|glib.PARAM_CONSTRUCT_ONLY,
+		))
+	})
+
+	ids = map[uintptr]string{}
+
+	idImplType = registerIDImpl()
+)
+
+func registerLevelImpl() glib.Type {
+	t := glib.RegisterSubclass(glib.TYPE_OBJECT, "GoGlibTestLevelImpl", func(klass *glib.ObjectClass) {
+		klass.OverrideProperty("level",
+			func(obj *glib.Object, value *glib.Value) {
+				value.SetInt(levels[obj.Native()])
+			},
+			func(obj *glib.Object, value *glib.Value) {
+				v, _ := value.GoValue()
+				levels[obj.Native()] = v.(int)
+			},
+		)
+	}, nil)
+
+	glib.TypeAddInterfaceStatic(t, levelIfaceType)
+	return t
+}
+
+func registerIDImpl() glib.Type {
+	t := glib.RegisterSubclass(glib.TYPE_OBJECT, "GoGlibTestIDImpl", func(klass *glib.ObjectClass) {
+		klass.OverrideProperty("id",
+			func(obj *glib.Object, value *glib.Value) {
+				value.SetString(ids[obj.Native()])
+			},
+			func(obj *glib.Object, value *glib.Value) {
+				v, _ := value.GoValue()
+				ids[obj.Native()] = v.(string)
+			},
+		)
+	}, nil)
+
+	glib.TypeAddInterfaceStatic(t, idIfaceType)
+	return t
+}
+
+func TestInterfaceProperty(t *testing.T) {
+	obj := glib.ObjectNew(levelImplType)
+
+	if !obj.IsA(levelIfaceType) {
+		t.Fatal("Expected object to implement", levelIfaceType.Name())
+	}
+
+	if err := obj.SetProperty("level", 42); err != nil {
+		t.Fatal("Failed to set level:", err)
+	}
+
+	level, err := obj.GetProperty("level")
+	if err != nil {
+		t.Fatal("Failed to get level:", err)
+	}
+	if level != 42 {
+		t.Error("Expected", 42, "got", level)
+	}
+}
+
+func TestObjectNewWithInterfaceConstructProperty(t *testing.T) {
+	obj, err := glib.ObjectNewWithProperties(idImplType, map[string]interface{}{
+		"id": "constructed",
+	})
+	if err != nil {
+		t.Fatal("Failed to construct object:", err)
+	}
+
+	id, err := obj.GetProperty("id")
+	if err != nil {
+		t.Fatal("Failed to get id:", err)
+	}
+	if id != "constructed" {
+		t.Error("Expected", "constructed", "got", id)
+	}
+
+	if _, err := glib.ObjectNewWithProperties(idImplType, map[string]interface{}{"missing": 1}); err == nil {
+		t.Error("Expected an error for a missing property")
+	}
+	if _, err := glib.ObjectNewWithProperties(idImplType, map[string]interface{}{"id": glib.ObjectNew(glib.TYPE_OBJECT)}); err == nil {
+		t.Error("Expected an error for a value of the wrong type")
+	}
+}
+
+// counter is the instance data of counterType.
+type counter struct {
+	count       int
+	initialized bool
+}
+
+func (c *counter) increment() {
+	c.count++
+}
+
+var counterType = registerCounter()
+
+func registerCounter() glib.Type {
+	t := glib.RegisterSubclass(glib.TYPE_OBJECT, "GoGlibTestCounter", nil, func(obj *glib.Object) {
+		obj.InstanceData().(*counter).initialized = true
+	})
+
+	glib.RegisterInstanceData(t, func() interface{} { return &counter{} })
+	return t
+}
+
+func TestInstanceData(t *testing.T) {
+	objects := make([]*glib.Object, 3)
+	for i := range objects {
+		objects[i] = glib.ObjectNew(counterType)
+	}
+
+	for i, obj := range objects {
+		for j := 0; j <= i; j++ {
+			obj.InstanceData().(*counter).increment()
+		}
+	}
+
+	for i, obj := range objects {
+		c := obj.InstanceData().(*counter)
+		if c.count != i+1 {
+			t.Error("Expected", i+1, "got", c.count)
+		}
+		if !c.initialized {
+			t.Error("Expected the data to be available from the instance init function")
+		}
+	}
+
+	if data := glib.ObjectNew(glib.TYPE_OBJECT).InstanceData(); data != nil {
+		t.Error("Expected no instance data, got", data)
+	}
+}
+
+func TestRegisterInstanceDataInitialized(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic, did not get one")
+		}
+	}()
+
+	glib.ObjectNew(counterType)
+	glib.RegisterInstanceData(counterType, func() interface{} { return nil })
+}
+
+func TestRegisterSubclassDuplicate(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic, did not get one")
+		}
+	}()
+
+	glib.RegisterSubclass(glib.TYPE_OBJECT, "GoGlibTestLevelImpl", nil, nil)
+}
+
+// notified records the notify overrides of the notifier types.
+var notified []string
+
+var (
+	notifierType        = registerNotifier(glib.TYPE_OBJECT, "GoGlibTestNotifier", "base")
+	derivedNotifierType = registerNotifier(notifierType, "GoGlibTestDerivedNotifier", "derived")
+)
+
+func registerNotifier(parent glib.Type, name, tag string) glib.Type {
+	return glib.RegisterSubclass(parent, name, func(klass *glib.ObjectClass) {
+		if parent == glib.TYPE_OBJECT {
+			values := map[string]int{}
+			for _, prop := range []string{"first", "second"} {
+				prop := prop
+				klass.InstallProperty(
+					glib.ParamSpecInt(prop, prop, prop, 0, 100, 0, glib.PARAM_READWRITE),
+					func(obj *glib.Object, value *glib.Value) { value.SetInt(values[prop]) },
+					func(obj *glib.Object, value *glib.Value) {
+						v, _ := value.GoValue()
+						values[prop] = v.(int)
+					},
+				)
+			}
+		}
+
+		klass.OverrideNotify(func(obj *glib.Object, pspec *glib.ParamSpec) {
+			notified = append(notified, tag+":"+pspec.Name())
+		})
+	}, nil)
+}
+
+func TestOverrideNotify(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		typ      glib.Type
+		expected []string
+	}{
+		{
+			desc:     "base",
+			typ:      notifierType,
+			expected: []string{"base:first", "base:second"},
+		},
+		{
+			desc:     "chained",
+			typ:      derivedNotifierType,
+			expected: []string{"derived:first", "base:first", "derived:second", "base:second"},
+		},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			obj := glib.ObjectNew(tC.typ)
+			notified = nil
+
+			obj.SetProperty("first", 1)
+			obj.SetProperty("second", 2)
+
+			if len(notified) != len(tC.expected) {
+				t.Fatal("Expected", tC.expected, "got", notified)
+			}
+			for i := range tC.expected {
+				if notified[i] != tC.expected[i] {
+					t.Error("Expected", tC.expected, "got", notified)
+					break
+				}
+			}
+		})
+	}
+}
+
+var simpleItemType = glib.RegisterSimpleObject("GoGlibTestSimpleItem", map[string]glib.SimpleProperty{
+	"title":   {Type: glib.TYPE_STRING, Default: "untitled"},
+	"count":   {Type: glib.TYPE_INT, Default: 3},
+	"done":    {Type: glib.TYPE_BOOLEAN},
+	"weight":  {Type: glib.TYPE_DOUBLE, Default: 0.5},
+	"related": {Type: glib.TYPE_OBJECT},
+})
+
+func TestRegisterSimpleObject(t *testing.T) {
+	item := glib.ObjectNew(simpleItemType)
+
+	expectProperties(t, item, map[string]interface{}{
+		"title":  "untitled",
+		"count":  3,
+		"done":   false,
+		"weight": 0.5,
+	})
+
+	count := item.CountNotifies("title", func() {
+		item.SetProperty("title", "first")
+		item.SetProperty("count", 7)
+		item.SetProperty("done", true)
+		item.SetProperty("weight", 1.5)
+	})
+	if count != 1 {
+		t.Error("Expected", 1, "notify, got", count)
+	}
+
+	expectProperties(t, item, map[string]interface{}{
+		"title":  "first",
+		"count":  7,
+		"done":   true,
+		"weight": 1.5,
+	})
+
+	// Values are per instance.
+	expectProperties(t, glib.ObjectNew(simpleItemType), map[string]interface{}{
+		"title": "untitled",
+		"count": 3,
+	})
+
+	related := newTestObject()
+	item.SetProperty("related", related)
+
+	v, err := item.GetProperty("related")
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if obj, _ := v.(*glib.Object); obj == nil || obj.Native() != related.Native() {
+		t.Error("Expected", related, "got", v)
+	}
+}
+
+func TestRegisterSimpleObjectInvalid(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic, did not get one")
+		}
+	}()
+
+	glib.RegisterSimpleObject("GoGlibTestSimpleInvalid", map[string]glib.SimpleProperty{
+		"count": {Type: glib.TYPE_INT, Default: "three"},
+	})
+}
+
+// widgetProps holds the properties of widgetType instances.
+var widgetProps = map[uintptr]map[string]interface{}{}
+
+var widgetType = registerWidget()
+
+func registerWidget() glib.Type {
+	t := glib.RegisterSubclass(glib.TYPE_OBJECT, "GoGlibTestWidget", nil, nil)
+
+	get := func(name string) glib.PropertyGetter {
+		return func(obj *glib.Object, value *glib.Value) {
+			switch v := widgetProps[obj.Native()][name].(type) {
+			case int:
+				value.SetInt(v)
+			case string:
+				value.SetString(v)
+			}
+		}
+	}
+	set := func(name string) glib.PropertySetter {
+		return func(obj *glib.Object, value *glib.Value) {
+			v, _ := value.GoValue()
+			if widgetProps[obj.Native()] == nil {
+				widgetProps[obj.Native()] = map[string]interface{}{}
+			}
+			widgetProps[obj.Native()][name] = v
+		}
+	}
+
+	glib.RegisterProperty(t,
+		glib.ParamSpecInt("width", "Width", "The width", 0, 1000, 0, glib.PARAM_READWRITE),
+		get("width"), set("width"))
+	glib.RegisterProperty(t,
+		glib.ParamSpecString("label", "Label", "The label", "", glib.PARAM_READWRITE),
+		get("label"), set("label"))
+
+	return t
+}
+
+func TestRegisterProperty(t *testing.T) {
+	obj := glib.ObjectNew(widgetType)
+
+	for _, name := range []string{"width", "label"} {
+		if obj.FindProperty(name) == nil {
+			t.Error("Expected to find property", name)
+		}
+	}
+
+	obj.SetProperty("width", 640)
+	obj.SetProperty("label", "OK")
+
+	if width := widgetProps[obj.Native()]["width"]; width != 640 {
+		t.Error("Expected the setter to store", 640, "got", width)
+	}
+
+	expectProperties(t, obj, map[string]interface{}{
+		"width": 640,
+		"label": "OK",
+	})
+
+	t.Run("initialized", func(t *testing.T) {
+		defer func() {
+			if recover() == nil {
+				t.Error("expected panic, did not get one")
+			}
+		}()
+
+		glib.RegisterProperty(widgetType,
+			glib.ParamSpecInt("height", "Height", "The height", 0, 1000, 0, glib.PARAM_READWRITE),
+			nil, nil)
+	})
+
+	t.Run("not from Go", func(t *testing.T) {
+		defer func() {
+			if recover() == nil {
+				t.Error("expected panic, did not get one")
+			}
+		}()
+
+		glib.RegisterProperty(glib.TYPE_OBJECT,
+			glib.ParamSpecInt("height", "Height", "The height", 0, 1000, 0, glib.PARAM_READWRITE),
+			nil, nil)
+	})
+}
+
+// dynamicUses counts the uses of dynamicPlugin minus its unuses.
+var dynamicUses int
+
+var (
+	dynamicPlugin = glib.NewTypePlugin(func() { dynamicUses++ }, func() { dynamicUses-- })
+
+	dynamicType = glib.RegisterDynamicType(dynamicPlugin, glib.TYPE_OBJECT, "GoGlibTestDynamic", func(klass *glib.ObjectClass) {
+		klass.InstallProperty(
+			glib.ParamSpecInt("answer", "Answer", "The answer", 0, 100, 0, glib.PARAM_READABLE),
+			func(obj *glib.Object, value *glib.Value) { value.SetInt(42) },
+			nil,
+		)
+	}, func(obj *glib.Object) { dynamicInits++ })
+
+	dynamicInits int
+)
+
+func TestRegisterDynamicType(t *testing.T) {
+	obj := glib.ObjectNew(dynamicType)
+
+	if !obj.IsA(dynamicType) {
+		t.Fatal("Expected object to be a", dynamicType.Name())
+	}
+	if dynamicUses < 1 {
+		t.Error("Expected the plugin to be in use, got", dynamicUses, "uses")
+	}
+	if dynamicInits != 1 {
+		t.Error("Expected", 1, "instance init, got", dynamicInits)
+	}
+
+	answer, err := obj.GetProperty("answer")
+	if err != nil {
+		t.Fatal("Failed to get answer:", err)
+	}
+	if answer != 42 {
+		t.Error("Expected", 42, "got", answer)
+	}
+}
+
+func TestTypeAncestry(t *testing.T) {
+	ancestry := derivedNotifierType.Ancestry()
+
+	expected := []glib.Type{derivedNotifierType, notifierType, glib.TYPE_OBJECT}
+	if len(ancestry) != len(expected) {
+		t.Fatal("Expected", expected, "got", ancestry)
+	}
+	for i := range expected {
+		if ancestry[i] != expected[i] {
+			t.Error("Expected", expected, "got", ancestry)
+			break
+		}
+	}
+
+	if ancestry := glib.TYPE_INVALID.Ancestry(); len(ancestry) != 0 {
+		t.Error("Expected no ancestry for TYPE_INVALID, got", ancestry)
+	}
+}
+
+func TestTypeChildren(t *testing.T) {
+	testCases := []struct {
+		desc   string
+		parent glib.Type
+		child  glib.Type
+	}{
+		{desc: "object", parent: glib.TYPE_OBJECT, child: notifierType},
+		{desc: "subclass", parent: notifierType, child: derivedNotifierType},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			for _, child := range tC.parent.Children() {
+				if child == tC.child {
+					return
+				}
+			}
+			t.Error("Expected", tC.child.Name(), "among the children of", tC.parent.Name())
+		})
+	}
+
+	if children := derivedNotifierType.Children(); len(children) != 0 {
+		t.Error("Expected no children, got", children)
+	}
+}
+
+func TestTypeHierarchy(t *testing.T) {
+	var names []string
+	for typ := derivedNotifierType; typ != glib.TYPE_INVALID; typ = typ.Parent() {
+		if len(names) > 10 {
+			t.Fatal("Expected the walk to end, got", names)
+		}
+		names = append(names, typ.Name())
+	}
+
+	expected := []string{"GoGlibTestDerivedNotifier", "GoGlibTestNotifier", "GObject"}
+	if len(names) != len(expected) {
+		t.Fatal("Expected", expected, "got", names)
+	}
+	for i := range expected {
+		if names[i] != expected[i] {
+			t.Error("Expected", expected, "got", names)
+			break
+		}
+	}
+
+	if !derivedNotifierType.IsA(glib.TYPE_OBJECT) {
+		t.Error("Expected", derivedNotifierType.Name(), "to be a GObject")
+	}
+	if glib.TYPE_OBJECT.IsA(derivedNotifierType) {
+		t.Error("Expected GObject not to be a", derivedNotifierType.Name())
+	}
+
+	if typ := glib.TypeFromName("GoGlibTestNotifier"); typ != notifierType {
+		t.Error("Expected", notifierType, "got", typ)
+	}
+	if typ := glib.TypeFromName("GoGlibTestMissing"); typ != glib.TYPE_INVALID {
+		t.Error("Expected", glib.TYPE_INVALID, "got", typ)
+	}
+
+	if name := glib.TYPE_INVALID.Name(); name != "" {
+		t.Error("Expected an empty name, got", name)
+	}
+	if parent := glib.TYPE_INVALID.Parent(); parent != glib.TYPE_INVALID {
+		t.Error("Expected", glib.TYPE_INVALID, "got", parent)
+	}
+}
@@ -0,0 +1,109 @@
+// Same copyright and license as the rest of the files in this project
+
+package glib
+
+// #include <glib.h>
+// #include <glib-object.h>
+// #include "gtypeplugin.go.h"
+import "C"
+
+import (
+	"fmt"
+	"sync"
+	"unsafe"
+)
+
+// TypePlugin is a GTypePlugin implemented in Go, which provides the dynamic
+// types registered using RegisterDynamicType. Unlike static types, the classes
+// of dynamic types are finalized once they're no longer used, after which the
+// plugin may unload whatever provides them, such as a loadable module.
+type TypePlugin struct {
+	*Object
+	use   func()
+	unuse func()
+}
+
+// typePlugins holds the TypePlugins by their GObjects. GLib keeps using a
+// plugin for as long as its types are registered, which is forever, so
+// plugins are never freed.
+var typePlugins = struct {
+	sync.RWMutex
+	plugins map[unsafe.Pointer]*TypePlugin
+}{
+	plugins: make(map[unsafe.Pointer]*TypePlugin),
+}
+
+// NewTypePlugin creates a new TypePlugin. use is called when GLib starts using
+// the types of the plugin, such as when the class of one of them is first
+// referenced, and should load whatever provides them. unuse is called once the
+// types aren't used anymore, after which they may be unloaded. Either function
+// may be nil.
+func NewTypePlugin(use, unuse func()) *TypePlugin {
+	plugin := &TypePlugin{
+		Object: ObjectNew(Type(C._go_type_plugin_get_type())),
+		use:    use,
+		unuse:  unuse,
+	}
+
+	typePlugins.Lock()
+	typePlugins.plugins[unsafe.Pointer(plugin.GObject)] = plugin
+	typePlugins.Unlock()
+
+	return plugin
+}
+
+// native returns a pointer to the underlying GTypePlugin.
+func (v *TypePlugin) native() *C.GTypePlugin {
+	if v == nil || v.Object == nil {
+		return nil
+	}
+	return (*C.GTypePlugin)(unsafe.Pointer(v.GObject))
+}
+
+func typePluginFromNative(plugin *C.GTypePlugin) *TypePlugin {
+	typePlugins.RLock()
+	defer typePlugins.RUnlock()
+
+	return typePlugins.plugins[unsafe.Pointer(plugin)]
+}
+
+//export goTypePluginUse
+func goTypePluginUse(plugin *C.GTypePlugin) {
+	if p := typePluginFromNative(plugin); p != nil && p.use != nil {
+		p.use()
+	}
+}
+
+//export goTypePluginUnuse
+func goTypePluginUnuse(plugin *C.GTypePlugin) {
+	if p := typePluginFromNative(plugin); p != nil && p.unuse != nil {
+		p.unuse()
+	}
+}
+
+// RegisterDynamicType is similar to RegisterSubclass, except the type is
+// registered using g_type_register_dynamic() and provided by plugin. Its class
+// is initialized using classInit again whenever it's used after having been
+// unloaded.
+//
+// RegisterDynamicType panics if parent is not a GObject type or if the name is
+// already taken.
+func RegisterDynamicType(plugin *TypePlugin, parent Type, name string, classInit func(klass *ObjectClass), instanceInit func(obj *Object)) Type {
+	if !parent.IsA(TYPE_OBJECT) {
+		panic(fmt.Sprintf("glib: cannot register %q: parent %s is not a GObject type", name, parent.Name()))
+	}
+
+	registeredTypes.Lock()
+	defer registeredTypes.Unlock()
+
+	t := registerTypeName(name, func(cname *C.gchar) C.GType {
+		return C.g_type_register_dynamic(C.GType(parent), cname, plugin.native(), 0)
+	})
+
+	registeredTypes.subclasses[t] = &subclass{
+		classInit:    classInit,
+		instanceInit: instanceInit,
+	}
+
+	return t
+}
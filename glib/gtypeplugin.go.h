@@ -0,0 +1,64 @@
+// Same copyright and license as the rest of the files in this project
+
+#ifndef __GTYPEPLUGIN_GO_H__
+#define __GTYPEPLUGIN_GO_H__
+
+#include <glib-object.h>
+#include <glib.h>
+
+extern void goClassInit(gpointer g_class, gpointer class_data);
+extern void goInstanceInit(GTypeInstance *instance, gpointer g_class);
+
+extern void goTypePluginUse(GTypePlugin *plugin);
+extern void goTypePluginUnuse(GTypePlugin *plugin);
+
+// Complete the type info of a dynamic type registered from Go the same way as
+// _g_type_register_subclass does for static types, so that its class and
+// instances are initialized from Go.
+static void _go_type_plugin_complete_type_info(GTypePlugin *plugin,
+                                               GType g_type, GTypeInfo *info,
+                                               GTypeValueTable *value_table) {
+  GTypeQuery query;
+
+  g_type_query(g_type_parent(g_type), &query);
+
+  info->class_size = query.class_size;
+  info->class_init = (GClassInitFunc)goClassInit;
+  info->instance_size = query.instance_size;
+  info->instance_init = (GInstanceInitFunc)goInstanceInit;
+}
+
+// Interfaces can't be added dynamically from Go, so there's nothing to
+// complete.
+static void _go_type_plugin_complete_interface_info(GTypePlugin *plugin,
+                                                    GType instance_type,
+                                                    GType interface_type,
+                                                    GInterfaceInfo *info) {}
+
+static void _go_type_plugin_iface_init(GTypePluginClass *iface) {
+  iface->use_plugin = goTypePluginUse;
+  iface->unuse_plugin = goTypePluginUnuse;
+  iface->complete_type_info = _go_type_plugin_complete_type_info;
+  iface->complete_interface_info = _go_type_plugin_complete_interface_info;
+}
+
+// Get the type of the GObjects implementing GTypePlugin for Go.
+static GType _go_type_plugin_get_type(void) {
+  static gsize type_id = 0;
+
+  if (g_once_init_enter(&type_id)) {
+    const GInterfaceInfo iface_info = {
+        (GInterfaceInitFunc)_go_type_plugin_iface_init, NULL, NULL};
+
+    GType id = g_type_register_static_simple(
+        G_TYPE_OBJECT, "GoGlibTypePlugin", sizeof(GObjectClass), NULL,
+        sizeof(GObject), NULL, 0);
+    g_type_add_interface_static(id, G_TYPE_TYPE_PLUGIN, &iface_info);
+
+    g_once_init_leave(&type_id, id);
+  }
+
+  return type_id;
+}
+
+#endif
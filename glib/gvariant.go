@@ -8,9 +8,9 @@ package glib
 import "C"
 
 import (
-	"errors"
 	"fmt"
 	"runtime"
+	"strings"
 	"unsafe"
 )
 
@@ -67,6 +67,19 @@ func newVariant(p *C.GVariant) *Variant {
 	return &Variant{GVariant: p}
 }
 
+// assumeVariant wraps a native GVariant that is fully owned by the caller and
+// not floating, such as one returned with transfer full. Unlike takeVariant, it
+// does not take another reference. A finalizer is set up to free the instance
+// during GC.
+func assumeVariant(p *C.GVariant) *Variant {
+	if p == nil {
+		return nil
+	}
+	obj := newVariant(p)
+	runtime.SetFinalizer(obj, (*Variant).Unref)
+	return obj
+}
+
 // TakeVariant wraps a unsafe.Pointer as a glib.Variant, taking ownership of it.
 // This function is exported for visibility in other gotk3 packages and
 // is not meant to be used by applications.
@@ -188,6 +201,96 @@ func VariantFromVariant(value *Variant) *Variant {
 	return takeVariant(C.g_variant_new_variant(value.native()))
 }
 
+// NewVariantHandle is a wrapper around g_variant_new_handle(). A handle is an
+// index into an array of file descriptors sent alongside the variant, such as
+// the GUnixFDList of a DBus message; it is not a file descriptor itself.
+func NewVariantHandle(index int32) *Variant {
+	return takeVariant(C.g_variant_new_handle(C.gint32(index)))
+}
+
+// NewVariantByteString creates a new "ay" variant holding the given bytes
+// followed by a nul terminator, which is the convention for byte strings, such
+// as file paths, in DBus. Unlike g_variant_new_bytestring(), embedded zeros are
+// kept.
+func NewVariantByteString(value []byte) *Variant {
+	cbytes := C.CBytes(append(value[:len(value):len(value)], 0))
+	defer C.free(cbytes)
+
+	c := C.g_variant_new_fixed_array(
+		VARIANT_TYPE_BYTE.native(), C.gconstpointer(cbytes), C.gsize(len(value)+1), 1)
+	return takeVariant(c)
+}
+
+// NewVariantByteStringArray creates a new "aay" variant holding the given byte
+// strings. See NewVariantByteString.
+func NewVariantByteStringArray(values [][]byte) *Variant {
+	children := make([]*Variant, len(values))
+	for i, value := range values {
+		children[i] = NewVariantByteString(value)
+	}
+
+	return NewVariantArray(VARIANT_TYPE_BYTESTRING, children)
+}
+
+// NewVariantArray is a wrapper around g_variant_new_array(). The children must
+// all be of the same type. childType may be nil if there's at least one child,
+// in which case the type of the children is used.
+func NewVariantArray(childType *VariantType, children []*Variant) *Variant {
+	cchildren := variantArray(children)
+	if cchildren != nil {
+		defer C.g_free(C.gpointer(cchildren))
+	}
+
+	c := C.g_variant_new_array(childType.native(), cchildren, C.gsize(len(children)))
+	runtime.KeepAlive(children)
+
+	return takeVariant(c)
+}
+
+// NewVariantTuple is a wrapper around g_variant_new_tuple(). No children
+// creates the unit tuple "()".
+func NewVariantTuple(children []*Variant) *Variant {
+	cchildren := variantArray(children)
+	if cchildren != nil {
+		defer C.g_free(C.gpointer(cchildren))
+	}
+
+	c := C.g_variant_new_tuple(cchildren, C.gsize(len(children)))
+	runtime.KeepAlive(children)
+
+	return takeVariant(c)
+}
+
+// variantArray allocates a C array of the given variants, which must be freed
+// using g_free(). nil is returned if there are none.
+func variantArray(variants []*Variant) **C.GVariant {
+	if len(variants) == 0 {
+		return nil
+	}
+
+	cvariants := C._g_variant_array_alloc(C.gsize(len(variants)))
+	for i, variant := range variants {
+		C._g_variant_array_set(cvariants, C.gsize(i), variant.native())
+	}
+
+	return cvariants
+}
+
+// NewVariantDictEntry is a wrapper around g_variant_new_dict_entry(). The key
+// must be of a basic type; it panics otherwise. Arrays of dictionary entries
+// form dictionaries, such as "a{sv}".
+func NewVariantDictEntry(key, value *Variant) *Variant {
+	if !gobool(C.g_variant_type_is_basic(C.g_variant_get_type(key.native()))) {
+		panic(fmt.Sprintf("glib: dictionary entry key of non-basic type %s", key.TypeString()))
+	}
+
+	c := C.g_variant_new_dict_entry(key.native(), value.native())
+	runtime.KeepAlive(key)
+	runtime.KeepAlive(value)
+
+	return takeVariant(c)
+}
+
 // TypeString returns the g variant type string for this variant.
 func (v *Variant) TypeString() string {
 	// the string returned from this belongs to GVariant and must not be freed.
@@ -209,6 +312,12 @@ func (v *Variant) GetDouble() float64 {
 	return float64(C.g_variant_get_double(v.native()))
 }
 
+// Int32 is a wrapper around g_variant_get_int32(). The variant must be of type
+// "i".
+func (v *Variant) Int32() int32 {
+	return int32(C.g_variant_get_int32(v.native()))
+}
+
 // GetString is a wrapper around g_variant_get_string.
 // It returns the string value of the variant.
 func (v *Variant) GetString() string {
@@ -224,6 +333,18 @@ func (v *Variant) GetString() string {
 	return C.GoStringN((*C.char)(gc), (C.int)(len))
 }
 
+// StringValue returns the string value of the variant and true if it's a
+// string, an object path or a signature, or an empty string and false
+// otherwise, where GetString would trigger a GLib critical.
+func (v *Variant) StringValue() (string, bool) {
+	switch v.TypeString() {
+	case "s", "o", "g":
+		return v.GetString(), true
+	default:
+		return "", false
+	}
+}
+
 // GetVariant is a wrapper around g_variant_get_variant.
 // It unboxes a nested GVariant.
 func (v *Variant) GetVariant() *Variant {
@@ -276,6 +397,61 @@ func (v *Variant) GetObjv() []string {
 	return strs
 }
 
+// Handle is a wrapper around g_variant_get_handle(). It returns the index of
+// the file descriptor of a variant of type "h". See NewVariantHandle.
+func (v *Variant) Handle() int32 {
+	return int32(C.g_variant_get_handle(v.native()))
+}
+
+// Compare is a wrapper around g_variant_compare(). It returns a negative number
+// if v is less than other, zero if they are equal, or a positive number if v is
+// greater. v and other must be of the same basic type, such as integers or
+// strings, otherwise Compare panics.
+func (v *Variant) Compare(other *Variant) int {
+	if !gobool(C.g_variant_type_is_basic(C.g_variant_get_type(v.native()))) {
+		panic(fmt.Sprintf("glib: cannot compare variants of non-basic type %s", v.TypeString()))
+	}
+	if !gobool(C.g_variant_type_equal(C.g_variant_get_type(v.native()), C.g_variant_get_type(other.native()))) {
+		panic(fmt.Sprintf("glib: cannot compare variants of types %s and %s", v.TypeString(), other.TypeString()))
+	}
+
+	return int(C.g_variant_compare(C.gconstpointer(v.native()), C.gconstpointer(other.native())))
+}
+
+// ByteString returns the bytes of an "ay" variant, without the nul terminator
+// of byte strings if present. Nil is returned if the variant is not of type
+// "ay".
+func (v *Variant) ByteString() []byte {
+	if !v.IsType(VARIANT_TYPE_BYTESTRING) {
+		return nil
+	}
+
+	var n C.gsize
+	c := C.g_variant_get_fixed_array(v.native(), &n, 1)
+
+	b := C.GoBytes(unsafe.Pointer(c), C.int(n))
+	if len(b) > 0 && b[len(b)-1] == 0 {
+		b = b[:len(b)-1]
+	}
+
+	return b
+}
+
+// ByteStringArray returns the byte strings of an "aay" variant. Nil is
+// returned if the variant is not of type "aay". See ByteString.
+func (v *Variant) ByteStringArray() [][]byte {
+	if !v.IsType(VARIANT_TYPE_BYTESTRING_ARRAY) {
+		return nil
+	}
+
+	values := make([][]byte, v.NChildren())
+	for i := range values {
+		values[i] = v.ChildValue(uint(i)).ByteString()
+	}
+
+	return values
+}
+
 // GetInt returns the int64 value of the variant if it is an integer type, and
 // an error otherwise.  It wraps variouns `g_variant_get_*` functions dealing
 // with integers of different sizes.
@@ -330,28 +506,102 @@ func (v *Variant) IsType(t *VariantType) bool {
 // String wraps g_variant_print().  It returns a string understood
 // by g_variant_parse().
 func (v *Variant) String() string {
-	gc := C.g_variant_print(v.native(), gbool(false))
-	defer C.g_free(C.gpointer(gc))
-	return C.GoString((*C.char)(gc))
+	return v.Print(false)
 }
 
 // AnnotatedString wraps g_variant_print(), but returns a type-annotated
 // string.
 func (v *Variant) AnnotatedString() string {
-	gc := C.g_variant_print(v.native(), gbool(true))
+	return v.Print(true)
+}
+
+// Print is a wrapper around g_variant_print(). The returned text can be parsed
+// back using VariantParse; if typeAnnotate is true, it includes the types that
+// can't be inferred from the values, so that it parses back to the same type.
+func (v *Variant) Print(typeAnnotate bool) string {
+	gc := C.g_variant_print(v.native(), gbool(typeAnnotate))
 	defer C.g_free(C.gpointer(gc))
 	return C.GoString((*C.char)(gc))
 }
 
+// NChildren is a wrapper around g_variant_n_children(). It returns the number
+// of children in a container variant, or 0 if the variant is not a container.
+func (v *Variant) NChildren() uint {
+	if !v.IsContainer() {
+		return 0
+	}
+	return uint(C.g_variant_n_children(v.native()))
+}
+
+// ChildValue is a wrapper around g_variant_get_child_value(). It returns the
+// child at the given index of a container variant. Nil is returned if the
+// index is out of range or if the variant is not a container, instead of
+// triggering a GLib critical.
+func (v *Variant) ChildValue(index uint) *Variant {
+	if index >= v.NChildren() {
+		return nil
+	}
+
+	c := C.g_variant_get_child_value(v.native(), C.gsize(index))
+	return assumeVariant(c)
+}
+
+// GetChild is the same as ChildValue.
+func (v *Variant) GetChild(index uint) *Variant {
+	return v.ChildValue(index)
+}
+
+// DictEntry returns the key and the value of a dictionary entry variant, such
+// as the children of an "a{sv}" dictionary. Nil is returned for both if the
+// variant is not a dictionary entry.
+func (v *Variant) DictEntry() (key, value *Variant) {
+	if !v.IsType(VARIANT_TYPE_DICT_ENTRY) {
+		return nil, nil
+	}
+
+	return v.ChildValue(0), v.ChildValue(1)
+}
+
+// LookupValue is a wrapper around g_variant_lookup_value(). It looks up the
+// given key in a dictionary variant and returns its value, or nil if the key
+// is missing or its value doesn't match expectedType. If expectedType is nil,
+// then the value may be of any type.
+func (v *Variant) LookupValue(key string, expectedType *VariantType) *Variant {
+	cstr := (*C.gchar)(C.CString(key))
+	defer C.free(unsafe.Pointer(cstr))
+
+	c := C.g_variant_lookup_value(v.native(), cstr, expectedType.native())
+	return assumeVariant(c)
+}
+
+// LookupPath looks up a value in nested "a{sv}" dictionaries, using each
+// element of path as the key of the next level. Nil is returned if any key is
+// missing or if any value but the last is not an "a{sv}" dictionary. An empty
+// path returns v itself.
+func (v *Variant) LookupPath(path ...string) *Variant {
+	for i, key := range path {
+		if v == nil || !v.IsType(VARIANT_TYPE_VARDICT) {
+			return nil
+		}
+
+		var expectedType *VariantType
+		if i < len(path)-1 {
+			expectedType = VARIANT_TYPE_VARDICT
+		}
+
+		v = v.LookupValue(key, expectedType)
+	}
+
+	return v
+}
+
 // TODO:
-//gint	g_variant_compare ()
 //GVariantClass	g_variant_classify ()
 //gboolean	g_variant_check_format_string ()
 //void	g_variant_get ()
 //void	g_variant_get_va ()
 //GVariant *	g_variant_new ()
 //GVariant *	g_variant_new_va ()
-//GVariant *	g_variant_new_handle ()
 //GVariant *	g_variant_new_printf ()
 //GVariant *	g_variant_new_object_path ()
 //gboolean	g_variant_is_object_path ()
@@ -359,8 +609,6 @@ func (v *Variant) AnnotatedString() string {
 //gboolean	g_variant_is_signature ()
 //GVariant *	g_variant_new_strv ()
 //GVariant *	g_variant_new_objv ()
-//GVariant *	g_variant_new_bytestring ()
-//GVariant *	g_variant_new_bytestring_array ()
 //guchar	g_variant_get_byte ()
 //gint16	g_variant_get_int16 ()
 //guint16	g_variant_get_uint16 ()
@@ -368,22 +616,13 @@ func (v *Variant) AnnotatedString() string {
 //guint32	g_variant_get_uint32 ()
 //gint64	g_variant_get_int64 ()
 //guint64	g_variant_get_uint64 ()
-//gint32	g_variant_get_handle ()
 //gdouble	g_variant_get_double ()
-//const gchar *	g_variant_get_bytestring ()
-//gchar *	g_variant_dup_bytestring ()
-//const gchar **	g_variant_get_bytestring_array ()
-//gchar **	g_variant_dup_bytestring_array ()
 //GVariant *	g_variant_new_maybe ()
 //GVariant *	g_variant_new_array ()
 //GVariant *	g_variant_new_tuple ()
-//GVariant *	g_variant_new_dict_entry ()
 //GVariant *	g_variant_new_fixed_array ()
 //GVariant *	g_variant_get_maybe ()
-//gsize	g_variant_n_children ()
-//GVariant *	g_variant_get_child_value ()
 //void	g_variant_get_child ()
-//GVariant *	g_variant_lookup_value ()
 //gboolean	g_variant_lookup ()
 //gconstpointer	g_variant_get_fixed_array ()
 //gsize	g_variant_get_size ()
@@ -432,15 +671,23 @@ func (v *Variant) AnnotatedString() string {
 //GVariant *	g_variant_dict_end ()
 //#define	G_VARIANT_PARSE_ERROR
 
-// VariantParse is a wrapper around g_variant_parse()
+// VariantParse is a wrapper around g_variant_parse(). vType may be nil if the
+// type can be inferred from the text. The error returned if the text can't be
+// parsed is a *GError whose message is the one of
+// g_variant_parse_error_print_context(), which gives the position of the error
+// in the text along with the message.
 func VariantParse(vType *VariantType, text string) (*Variant, error) {
 	cstr := C.CString(text)
 	defer C.free(unsafe.Pointer(cstr))
 	var gerr *C.GError
 	c := C.g_variant_parse(vType.native(), (*C.gchar)(cstr), nil, nil, &gerr)
 	if c == nil {
-		defer C.g_error_free(gerr)
-		return nil, errors.New(C.GoString(gerr.message))
+		context := C.g_variant_parse_error_print_context(gerr, (*C.gchar)(cstr))
+		defer C.g_free(C.gpointer(context))
+
+		err := takeGError(gerr)
+		err.message = strings.TrimSpace(C.GoString((*C.char)(context)))
+		return nil, err
 	}
 	// will be freed during GC
 	return takeVariant(c), nil
@@ -448,4 +695,3 @@ func VariantParse(vType *VariantType, text string) (*Variant, error) {
 
 //GVariant *	g_variant_new_parsed_va ()
 //GVariant *	g_variant_new_parsed ()
-//gchar *	g_variant_parse_error_print_context ()
@@ -23,4 +23,33 @@ static GVariantDict *toGVariantDict(void *p) { return (GVariantDict *)p; }
 
 static GVariantIter *toGVariantIter(void *p) { return (GVariantIter *)p; }
 
+static GVariant **_g_variant_array_alloc(gsize n) {
+  return (g_new0(GVariant *, n));
+}
+
+static void _g_variant_array_set(GVariant **array, gsize i, GVariant *value) {
+  array[i] = value;
+}
+
+// Merge two "a{sv}" dictionaries, with the keys of override taking precedence
+// over those of base. Either may be NULL.
+static GVariant *_g_variant_merge_dicts(GVariant *base, GVariant *override) {
+  GVariantDict dict;
+  GVariantIter iter;
+  const gchar *key;
+  GVariant *value;
+
+  g_variant_dict_init(&dict, base);
+
+  if (override != NULL) {
+    g_variant_iter_init(&iter, override);
+    while (g_variant_iter_next(&iter, "{&sv}", &key, &value)) {
+      g_variant_dict_insert_value(&dict, key, value);
+      g_variant_unref(value);
+    }
+  }
+
+  return g_variant_dict_end(&dict);
+}
+
 #endif
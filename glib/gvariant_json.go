@@ -0,0 +1,413 @@
+package glib
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+/*
+ * GVariant JSON conversion
+ *
+ * Variants are mapped to JSON as follows:
+ *
+ *    b                      boolean
+ *    y, n, q, i, u, x, t, h number
+ *    d                      number
+ *    s, o, g                string
+ *    v                      the boxed value
+ *    mT                     null if nothing, otherwise T
+ *    aT                     array
+ *    a{KT}                  object, with the keys formatted as strings
+ *    (T...)                 array
+ *    {KT}                   array of the key and the value
+ *
+ * When converting JSON to a variant of type "v", the type of the boxed value is
+ * inferred: booleans become "b", integers "x", other numbers "d", strings "s",
+ * arrays "av", objects "a{sv}" and null "mv".
+ */
+
+// VariantToJSON converts v to JSON. See JSONToVariant for the reverse.
+// Dictionaries are converted to objects with their keys sorted, so their order
+// is not kept.
+func VariantToJSON(v *Variant) ([]byte, error) {
+	value, err := variantJSONValue(v)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(value)
+}
+
+func variantJSONValue(v *Variant) (interface{}, error) {
+	switch ts := v.TypeString(); ts[0] {
+	case 'b':
+		return v.GetBoolean(), nil
+	case 'n', 'i', 'x':
+		return v.GetInt()
+	case 'y', 'q', 'u', 't':
+		return v.GetUint()
+	case 'h':
+		return v.Handle(), nil
+	case 'd':
+		d := v.GetDouble()
+		if math.IsNaN(d) || math.IsInf(d, 0) {
+			return nil, fmt.Errorf("cannot convert %v to JSON", d)
+		}
+		return d, nil
+	case 's', 'o', 'g':
+		return v.GetString(), nil
+	case 'v':
+		return variantJSONValue(v.GetVariant())
+	case 'm':
+		if v.NChildren() == 0 {
+			return nil, nil
+		}
+		return variantJSONValue(v.ChildValue(0))
+	case 'a':
+		if ts[1] == '{' {
+			return variantJSONObject(v)
+		}
+		return variantJSONArray(v)
+	case '(', '{':
+		return variantJSONArray(v)
+	default:
+		return nil, fmt.Errorf("cannot convert variant of type %s to JSON", ts)
+	}
+}
+
+func variantJSONArray(v *Variant) ([]interface{}, error) {
+	values := make([]interface{}, v.NChildren())
+	for i := range values {
+		value, err := variantJSONValue(v.ChildValue(uint(i)))
+		if err != nil {
+			return nil, err
+		}
+		values[i] = value
+	}
+
+	return values, nil
+}
+
+func variantJSONObject(v *Variant) (map[string]interface{}, error) {
+	object := make(map[string]interface{}, v.NChildren())
+
+	for i := uint(0); i < v.NChildren(); i++ {
+		entry := v.ChildValue(i)
+
+		key, err := variantJSONValue(entry.ChildValue(0))
+		if err != nil {
+			return nil, err
+		}
+
+		value, err := variantJSONValue(entry.ChildValue(1))
+		if err != nil {
+			return nil, err
+		}
+
+		object[fmt.Sprint(key)] = value
+	}
+
+	return object, nil
+}
+
+// JSONToVariant converts JSON to a variant of the given type, which must be
+// definite. The JSON must follow the mapping used by VariantToJSON, except that
+// the type of values boxed in "v" is inferred. An error is returned if the JSON
+// doesn't match the type.
+func JSONToVariant(data []byte, typeString string) (*Variant, error) {
+	if !VariantTypeStringIsValid(typeString) {
+		return nil, fmt.Errorf("invalid variant type %q", typeString)
+	}
+	if strings.ContainsAny(typeString, "*?r") {
+		return nil, fmt.Errorf("variant type %q is not definite", typeString)
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var value interface{}
+	if err := dec.Decode(&value); err != nil {
+		return nil, err
+	}
+
+	// Decode stops after the first value, so make sure that it's the only one.
+	var extra interface{}
+	if err := dec.Decode(&extra); err != io.EOF {
+		return nil, errors.New("unexpected data after JSON value")
+	}
+
+	var text strings.Builder
+	if err := writeVariantText(&text, typeString, value); err != nil {
+		return nil, err
+	}
+
+	return VariantParse(VariantTypeNew(typeString), text.String())
+}
+
+// variantTypeLen returns the length of the first complete type in the given
+// type string.
+func variantTypeLen(typeString string) int {
+	switch typeString[0] {
+	case 'a', 'm':
+		return 1 + variantTypeLen(typeString[1:])
+	case '(', '{':
+		i := 1
+		for typeString[i] != ')' && typeString[i] != '}' {
+			i += variantTypeLen(typeString[i:])
+		}
+		return i + 1
+	default:
+		return 1
+	}
+}
+
+// writeVariantText writes value in the GVariant text format as a value of the
+// given type. The whole value is parsed with its type known, so only values
+// boxed in variants need type annotations.
+func writeVariantText(text *strings.Builder, typeString string, value interface{}) error {
+	mismatch := func() error {
+		return fmt.Errorf("cannot convert JSON %v to variant type %s", value, typeString)
+	}
+
+	switch typeString[0] {
+	case 'b':
+		b, ok := value.(bool)
+		if !ok {
+			return mismatch()
+		}
+		text.WriteString(strconv.FormatBool(b))
+
+	case 'y', 'n', 'q', 'i', 'u', 'x', 't', 'h':
+		n, ok := value.(json.Number)
+		if !ok {
+			return mismatch()
+		}
+		if _, err := strconv.ParseInt(n.String(), 10, 64); err != nil {
+			if _, err := strconv.ParseUint(n.String(), 10, 64); err != nil {
+				return mismatch()
+			}
+		}
+		text.WriteString(n.String())
+
+	case 'd':
+		n, ok := value.(json.Number)
+		if !ok {
+			return mismatch()
+		}
+		f, err := n.Float64()
+		if err != nil {
+			return mismatch()
+		}
+		text.WriteString(strconv.FormatFloat(f, 'g', -1, 64))
+
+	case 's', 'o', 'g':
+		s, ok := value.(string)
+		if !ok {
+			return mismatch()
+		}
+		writeVariantString(text, s)
+
+	case 'v':
+		return writeInferredVariantText(text, value)
+
+	case 'm':
+		if value == nil {
+			text.WriteString("nothing")
+			return nil
+		}
+		text.WriteString("just ")
+		return writeVariantText(text, typeString[1:], value)
+
+	case 'a':
+		if typeString[1] == '{' {
+			object, ok := value.(map[string]interface{})
+			if !ok {
+				return mismatch()
+			}
+			return writeVariantDictText(text, typeString[1:], object)
+		}
+
+		array, ok := value.([]interface{})
+		if !ok {
+			return mismatch()
+		}
+
+		text.WriteByte('[')
+		for i, elem := range array {
+			if i > 0 {
+				text.WriteString(", ")
+			}
+			if err := writeVariantText(text, typeString[1:], elem); err != nil {
+				return err
+			}
+		}
+		text.WriteByte(']')
+
+	case '(':
+		array, ok := value.([]interface{})
+		if !ok {
+			return mismatch()
+		}
+
+		text.WriteByte('(')
+		rest := typeString[1 : len(typeString)-1]
+		for i, elem := range array {
+			if rest == "" {
+				return mismatch()
+			}
+			if i > 0 {
+				text.WriteString(", ")
+			}
+			n := variantTypeLen(rest)
+			if err := writeVariantText(text, rest[:n], elem); err != nil {
+				return err
+			}
+			rest = rest[n:]
+		}
+		if rest != "" {
+			return mismatch()
+		}
+		if len(array) == 1 {
+			text.WriteByte(',')
+		}
+		text.WriteByte(')')
+
+	case '{':
+		array, ok := value.([]interface{})
+		if !ok || len(array) != 2 {
+			return mismatch()
+		}
+
+		keyLen := variantTypeLen(typeString[1:])
+		keyType := typeString[1 : 1+keyLen]
+		valueType := typeString[1+keyLen : len(typeString)-1]
+
+		text.WriteByte('{')
+		if err := writeVariantText(text, keyType, array[0]); err != nil {
+			return err
+		}
+		text.WriteString(", ")
+		if err := writeVariantText(text, valueType, array[1]); err != nil {
+			return err
+		}
+		text.WriteByte('}')
+
+	default:
+		return mismatch()
+	}
+
+	return nil
+}
+
+// writeVariantDictText writes the given object as a dictionary, where
+// entryType is the type string of the dictionary entries.
+func writeVariantDictText(text *strings.Builder, entryType string, object map[string]interface{}) error {
+	keyLen := variantTypeLen(entryType[1:])
+	keyType := entryType[1 : 1+keyLen]
+	valueType := entryType[1+keyLen : len(entryType)-1]
+
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	text.WriteByte('{')
+	for i, key := range keys {
+		if i > 0 {
+			text.WriteString(", ")
+		}
+
+		// Keys are always strings in JSON, so convert them back to what the
+		// key type expects.
+		var keyValue interface{} = key
+		switch keyType[0] {
+		case 'b':
+			b, err := strconv.ParseBool(key)
+			if err != nil {
+				return fmt.Errorf("cannot convert JSON key %q to variant type %s", key, keyType)
+			}
+			keyValue = b
+		case 'y', 'n', 'q', 'i', 'u', 'x', 't', 'h', 'd':
+			keyValue = json.Number(key)
+		}
+
+		if err := writeVariantText(text, keyType, keyValue); err != nil {
+			return err
+		}
+		text.WriteString(": ")
+		if err := writeVariantText(text, valueType, object[key]); err != nil {
+			return err
+		}
+	}
+	text.WriteByte('}')
+
+	return nil
+}
+
+// writeInferredVariantText writes value boxed in a variant, inferring its type
+// from the JSON value.
+func writeInferredVariantText(text *strings.Builder, value interface{}) error {
+	text.WriteByte('<')
+
+	switch value := value.(type) {
+	case nil:
+		text.WriteString("@mv nothing")
+	case bool:
+		text.WriteString(strconv.FormatBool(value))
+	case json.Number:
+		if _, err := value.Int64(); err == nil {
+			text.WriteString("int64 ")
+			text.WriteString(value.String())
+		} else {
+			f, err := value.Float64()
+			if err != nil {
+				return fmt.Errorf("cannot convert JSON number %s to a variant", value)
+			}
+			// Ensure that the number is parsed as a double.
+			text.WriteString("@d ")
+			text.WriteString(strconv.FormatFloat(f, 'g', -1, 64))
+		}
+	case string:
+		writeVariantString(text, value)
+	case []interface{}:
+		text.WriteString("@av ")
+		if err := writeVariantText(text, "av", value); err != nil {
+			return err
+		}
+	case map[string]interface{}:
+		text.WriteString("@a{sv} ")
+		if err := writeVariantDictText(text, "{sv}", value); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("cannot convert JSON %v to a variant", value)
+	}
+
+	text.WriteByte('>')
+	return nil
+}
+
+// writeVariantString writes s as a quoted string in the GVariant text format.
+func writeVariantString(text *strings.Builder, s string) {
+	text.WriteByte('\'')
+	for _, r := range s {
+		switch {
+		case r == '\'' || r == '\\':
+			text.WriteByte('\\')
+			text.WriteRune(r)
+		case r < 0x20 || r == 0x7f:
+			fmt.Fprintf(text, "\\u%04x", r)
+		default:
+			text.WriteRune(r)
+		}
+	}
+	text.WriteByte('\'')
+}
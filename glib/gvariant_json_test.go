@@ -0,0 +1,130 @@
+// Same copyright and license as the rest of the files in this project
+
+package glib_test
+
+import (
+	"testing"
+
+	"github.com/diamondburned/go-glib/glib"
+)
+
+func TestVariantJSONRoundTrip(t *testing.T) {
+	testCases := []struct {
+		desc       string
+		typeString string
+		json       string
+	}{
+		{desc: "boolean", typeString: "b", json: `true`},
+		{desc: "byte", typeString: "y", json: `255`},
+		{desc: "int64", typeString: "x", json: `-9007199254740993`},
+		{desc: "uint64", typeString: "t", json: `18446744073709551615`},
+		{desc: "double", typeString: "d", json: `1.5`},
+		{desc: "string", typeString: "s", json: `"it's \"quoted\"\n"`},
+		{desc: "object path", typeString: "o", json: `"/org/example"`},
+		{desc: "maybe nothing", typeString: "mi", json: `null`},
+		{desc: "maybe just", typeString: "mi", json: `3`},
+		{desc: "tuple", typeString: "(is)", json: `[1,"one"]`},
+		{desc: "single tuple", typeString: "(s)", json: `["one"]`},
+		{desc: "integer keys", typeString: "a{ib}", json: `{"1":true,"2":false}`},
+		{
+			desc:       "nested",
+			typeString: "a{s(iasmd)}",
+			json:       `{"a":[1,["x","y"],null],"b":[2,[],2.5]}`,
+		},
+		{
+			desc:       "variants",
+			typeString: "a{sv}",
+			json:       `{"array":[1,"two",false],"float":0.5,"int":1,"null":null,"object":{"nested":"value"}}`,
+		},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			v, err := glib.JSONToVariant([]byte(tC.json), tC.typeString)
+			if err != nil {
+				t.Fatal("Unexpected error:", err)
+			}
+			if v.TypeString() != tC.typeString {
+				t.Error("Expected type", tC.typeString, "got", v.TypeString())
+			}
+
+			data, err := glib.VariantToJSON(v)
+			if err != nil {
+				t.Fatal("Unexpected error:", err)
+			}
+			if string(data) != tC.json {
+				t.Error("Expected", tC.json, "got", string(data))
+			}
+		})
+	}
+}
+
+func TestVariantToJSONTypes(t *testing.T) {
+	v, err := glib.JSONToVariant([]byte(`{"n":1,"d":1,"s":"1"}`), "a{sv}")
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+
+	expected := map[string]string{"n": "x", "d": "x", "s": "s"}
+	for key, typeString := range expected {
+		child := v.LookupValue(key, nil)
+		if child == nil {
+			t.Error("Missing key", key)
+			continue
+		}
+		if child.TypeString() != typeString {
+			t.Error("Expected", typeString, "for", key, "got", child.TypeString())
+		}
+	}
+
+	// The declared type is kept even where JSON can't tell the difference.
+	v, err = glib.JSONToVariant([]byte(`[1,1]`), "(qd)")
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if v.TypeString() != "(qd)" {
+		t.Error("Expected", "(qd)", "got", v.TypeString())
+	}
+	if d := v.ChildValue(1).GetDouble(); d != 1 {
+		t.Error("Expected", 1, "got", d)
+	}
+}
+
+func TestJSONToVariantErrors(t *testing.T) {
+	testCases := []struct {
+		desc       string
+		typeString string
+		json       string
+	}{
+		{desc: "invalid type", typeString: "a{", json: `{}`},
+		{desc: "indefinite type", typeString: "a*", json: `[]`},
+		{desc: "invalid JSON", typeString: "i", json: `{`},
+		{desc: "wrong kind", typeString: "i", json: `"1"`},
+		{desc: "fraction for integer", typeString: "i", json: `1.5`},
+		{desc: "out of range", typeString: "y", json: `256`},
+		{desc: "short tuple", typeString: "(ii)", json: `[1]`},
+		{desc: "long tuple", typeString: "(i)", json: `[1,2]`},
+		{desc: "bad key", typeString: "a{iv}", json: `{"one":1}`},
+		{desc: "second value", typeString: "i", json: `1 2`},
+		{desc: "trailing garbage", typeString: "a{sv}", json: `{"a":1} garbage`},
+		{desc: "trailing bracket", typeString: "i", json: `1]`},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			if _, err := glib.JSONToVariant([]byte(tC.json), tC.typeString); err == nil {
+				t.Error("Expected error for", tC.json, "as", tC.typeString)
+			}
+		})
+	}
+}
+
+func TestJSONToVariantTrailingSpace(t *testing.T) {
+	v, err := glib.JSONToVariant([]byte("1\n\t "), "i")
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if v.String() != "1" {
+		t.Error("Expected", "1", "got", v.String())
+	}
+}
@@ -3,7 +3,10 @@
 package glib_test
 
 import (
+	"bytes"
+	"errors"
 	"math"
+	"strings"
 	"testing"
 
 	"github.com/diamondburned/go-glib/glib"
@@ -186,3 +189,464 @@ func TestVariantVariant(t *testing.T) {
 		t.Error("Expected", boxed.Native(), "got", actual.Native())
 	}
 }
+
+func TestMergeVariantDicts(t *testing.T) {
+	base, err := glib.VariantParse(glib.VARIANT_TYPE_VARDICT,
+		"{'width': <int32 640>, 'height': <int32 480>, 'title': <'base'>}")
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+
+	override, err := glib.VariantParse(glib.VARIANT_TYPE_VARDICT,
+		"{'width': <int32 1280>, 'title': <true>, 'fullscreen': <false>}")
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+
+	merged := glib.MergeVariantDicts(base, override)
+	if !merged.IsType(glib.VARIANT_TYPE_VARDICT) {
+		t.Fatal("Expected a{sv}, got", merged.TypeString())
+	}
+
+	testCases := []struct {
+		desc     string
+		key      string
+		expected string
+	}{
+		{
+			desc:     "overlapping key is overridden",
+			key:      "width",
+			expected: "1280",
+		},
+		{
+			desc:     "key only in base is kept",
+			key:      "height",
+			expected: "480",
+		},
+		{
+			desc:     "override wins regardless of type",
+			key:      "title",
+			expected: "true",
+		},
+		{
+			desc:     "key only in override is added",
+			key:      "fullscreen",
+			expected: "false",
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			value := merged.LookupValue(tC.key, nil)
+			if value == nil {
+				t.Fatal("Expected key", tC.key, "to exist")
+			}
+			if actual := value.String(); actual != tC.expected {
+				t.Error("Expected", tC.expected, "got", actual)
+			}
+		})
+	}
+
+	if value := merged.LookupValue("missing", nil); value != nil {
+		t.Error("Expected nil for missing key, got", value)
+	}
+}
+
+func TestMergeVariantDictsNil(t *testing.T) {
+	base, err := glib.VariantParse(glib.VARIANT_TYPE_VARDICT, "{'a': <'b'>}")
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+
+	merged := glib.MergeVariantDicts(base, nil)
+	if actual := merged.LookupValue("a", glib.VARIANT_TYPE_STRING); actual == nil || actual.GetString() != "b" {
+		t.Error("Expected 'b', got", actual)
+	}
+
+	empty := glib.MergeVariantDicts(nil, nil)
+	if actual := empty.TypeString(); actual != "a{sv}" {
+		t.Error("Expected a{sv}, got", actual)
+	}
+	if actual := empty.LookupValue("a", nil); actual != nil {
+		t.Error("Expected empty dictionary, got", empty)
+	}
+}
+
+func TestVariantChildValue(t *testing.T) {
+	t.Run("tuple", func(t *testing.T) {
+		tuple, err := glib.VariantParse(nil, "(int32 1, 'two', true)")
+		if err != nil {
+			t.Fatal("Unexpected error:", err)
+		}
+
+		if n := tuple.NChildren(); n != 3 {
+			t.Fatal("Expected", 3, "got", n)
+		}
+
+		expected := []string{"1", "'two'", "true"}
+		for i, e := range expected {
+			child := tuple.ChildValue(uint(i))
+			if child == nil {
+				t.Fatal("Expected child", i, "got nil")
+			}
+			if actual := child.String(); actual != e {
+				t.Error("Expected", e, "got", actual)
+			}
+		}
+
+		if child := tuple.ChildValue(3); child != nil {
+			t.Error("Expected nil for out-of-range index, got", child)
+		}
+	})
+
+	t.Run("array", func(t *testing.T) {
+		array, err := glib.VariantParse(glib.VariantTypeNew("as"), "['a', 'b']")
+		if err != nil {
+			t.Fatal("Unexpected error:", err)
+		}
+
+		if n := array.NChildren(); n != 2 {
+			t.Fatal("Expected", 2, "got", n)
+		}
+		if actual := array.ChildValue(1).GetString(); actual != "b" {
+			t.Error("Expected", "b", "got", actual)
+		}
+		if child := array.ChildValue(2); child != nil {
+			t.Error("Expected nil for out-of-range index, got", child)
+		}
+	})
+
+	t.Run("non-container", func(t *testing.T) {
+		variant := glib.VariantFromInt32(1)
+		if n := variant.NChildren(); n != 0 {
+			t.Error("Expected", 0, "got", n)
+		}
+		if child := variant.ChildValue(0); child != nil {
+			t.Error("Expected nil, got", child)
+		}
+	})
+}
+
+func TestVariantLookupPath(t *testing.T) {
+	config, err := glib.VariantParse(glib.VARIANT_TYPE_VARDICT,
+		"{'window': <{'size': <{'width': <int32 640>}>}>, 'title': <'app'>}")
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+
+	testCases := []struct {
+		desc     string
+		path     []string
+		expected string
+	}{
+		{
+			desc:     "two levels",
+			path:     []string{"window", "size"},
+			expected: "{'width': <640>}",
+		},
+		{
+			desc:     "three levels",
+			path:     []string{"window", "size", "width"},
+			expected: "640",
+		},
+		{
+			desc: "missing middle key",
+			path: []string{"window", "position", "x"},
+		},
+		{
+			desc: "non-dictionary middle value",
+			path: []string{"title", "size"},
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			actual := config.LookupPath(tC.path...)
+			if tC.expected == "" {
+				if actual != nil {
+					t.Error("Expected nil, got", actual)
+				}
+				return
+			}
+			if actual == nil {
+				t.Fatal("Expected", tC.expected, "got nil")
+			}
+			if actual.String() != tC.expected {
+				t.Error("Expected", tC.expected, "got", actual.String())
+			}
+		})
+	}
+}
+
+func TestVariantByteString(t *testing.T) {
+	value := []byte("path\x00with\x00zeros")
+
+	v := glib.NewVariantByteString(value)
+	if ts := v.TypeString(); ts != "ay" {
+		t.Fatal("Expected ay, got", ts)
+	}
+	if actual := v.ByteString(); !bytes.Equal(actual, value) {
+		t.Errorf("Expected %q, got %q", value, actual)
+	}
+
+	if actual := glib.VariantFromString("s").ByteString(); actual != nil {
+		t.Errorf("Expected nil for a non-ay variant, got %q", actual)
+	}
+}
+
+func TestVariantByteStringArray(t *testing.T) {
+	values := [][]byte{[]byte("a\x00b"), {}, []byte("c")}
+
+	v := glib.NewVariantByteStringArray(values)
+	if ts := v.TypeString(); ts != "aay" {
+		t.Fatal("Expected aay, got", ts)
+	}
+
+	actual := v.ByteStringArray()
+	if len(actual) != len(values) {
+		t.Fatal("Expected", len(values), "byte strings, got", len(actual))
+	}
+	for i := range values {
+		if !bytes.Equal(actual[i], values[i]) {
+			t.Errorf("Expected %q at %d, got %q", values[i], i, actual[i])
+		}
+	}
+
+	if empty := glib.NewVariantByteStringArray(nil).ByteStringArray(); len(empty) != 0 {
+		t.Error("Expected no byte strings, got", empty)
+	}
+}
+
+func TestVariantHandle(t *testing.T) {
+	v := glib.NewVariantHandle(3)
+	if v.TypeString() != "h" {
+		t.Error("Expected type", "h", "got", v.TypeString())
+	}
+	if v.Handle() != 3 {
+		t.Error("Expected", 3, "got", v.Handle())
+	}
+
+	tuple, err := glib.VariantParse(glib.VariantTypeNew("(hs)"), "(handle 1, 'fd')")
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if handle := tuple.ChildValue(0).Handle(); handle != 1 {
+		t.Error("Expected", 1, "got", handle)
+	}
+}
+
+func TestVariantCompare(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		a, b     *glib.Variant
+		expected int
+	}{
+		{desc: "int less", a: glib.VariantFromInt32(-5), b: glib.VariantFromInt32(3), expected: -1},
+		{desc: "int equal", a: glib.VariantFromInt32(3), b: glib.VariantFromInt32(3), expected: 0},
+		{desc: "uint greater", a: glib.VariantFromUint64(10), b: glib.VariantFromUint64(2), expected: 1},
+		{desc: "string less", a: glib.VariantFromString("apple"), b: glib.VariantFromString("banana"), expected: -1},
+		{desc: "string equal", a: glib.VariantFromString("pear"), b: glib.VariantFromString("pear"), expected: 0},
+		{desc: "string greater", a: glib.VariantFromString("b"), b: glib.VariantFromString("a"), expected: 1},
+	}
+
+	sign := func(n int) int {
+		switch {
+		case n < 0:
+			return -1
+		case n > 0:
+			return 1
+		default:
+			return 0
+		}
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			if actual := sign(tC.a.Compare(tC.b)); actual != tC.expected {
+				t.Error("Expected", tC.expected, "got", actual)
+			}
+		})
+	}
+
+	invalid := []struct {
+		desc string
+		a, b *glib.Variant
+	}{
+		{desc: "mismatched types", a: glib.VariantFromInt32(1), b: glib.VariantFromString("1")},
+		{desc: "container", a: glib.NewVariantByteString(nil), b: glib.NewVariantByteString(nil)},
+	}
+
+	for _, tC := range invalid {
+		t.Run(tC.desc, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("expected panic, did not get one")
+				}
+			}()
+
+			tC.a.Compare(tC.b)
+		})
+	}
+}
+
+func TestVariantDictEntry(t *testing.T) {
+	entry := glib.NewVariantDictEntry(
+		glib.VariantFromString("answer"),
+		glib.VariantFromVariant(glib.VariantFromInt32(42)),
+	)
+	if ts := entry.TypeString(); ts != "{sv}" {
+		t.Error("Expected", "{sv}", "got", ts)
+	}
+
+	key, value := entry.DictEntry()
+	if key.GetString() != "answer" {
+		t.Error("Expected", "answer", "got", key.GetString())
+	}
+	if value.String() != "<42>" {
+		t.Error("Expected", "<42>", "got", value.String())
+	}
+
+	t.Run("dictionary", func(t *testing.T) {
+		dict, err := glib.VariantParse(glib.VARIANT_TYPE_VARDICT, "{'a': <1>, 'b': <2>}")
+		if err != nil {
+			t.Fatal("Unexpected error:", err)
+		}
+
+		var keys []string
+		for i := uint(0); i < dict.NChildren(); i++ {
+			key, _ := dict.ChildValue(i).DictEntry()
+			keys = append(keys, key.GetString())
+		}
+		if len(keys) != 2 || keys[0] != "a" || keys[1] != "b" {
+			t.Error("Expected", []string{"a", "b"}, "got", keys)
+		}
+	})
+
+	t.Run("not an entry", func(t *testing.T) {
+		key, value := glib.VariantFromInt32(1).DictEntry()
+		if key != nil || value != nil {
+			t.Error("Expected nil, got", key, value)
+		}
+	})
+
+	t.Run("non-basic key", func(t *testing.T) {
+		defer func() {
+			if recover() == nil {
+				t.Error("expected panic, did not get one")
+			}
+		}()
+
+		glib.NewVariantDictEntry(entry, glib.VariantFromInt32(1))
+	})
+}
+
+func TestVariantTuple(t *testing.T) {
+	inner := glib.NewVariantTuple([]*glib.Variant{
+		glib.VariantFromBoolean(true),
+		glib.NewVariantArray(glib.VARIANT_TYPE_STRING, []*glib.Variant{
+			glib.VariantFromString("a"),
+			glib.VariantFromString("b"),
+		}),
+	})
+	tuple := glib.NewVariantTuple([]*glib.Variant{
+		glib.VariantFromInt32(-5),
+		glib.VariantFromString("foo"),
+		inner,
+	})
+
+	if ts := tuple.TypeString(); ts != "(is(bas))" {
+		t.Fatal("Expected", "(is(bas))", "got", ts)
+	}
+
+	if i := tuple.ChildValue(0).Int32(); i != -5 {
+		t.Error("Expected", -5, "got", i)
+	}
+	if s, ok := tuple.GetChild(1).StringValue(); !ok || s != "foo" {
+		t.Error("Expected", "foo", "got", s)
+	}
+	if _, ok := tuple.GetChild(0).StringValue(); ok {
+		t.Error("Expected an int32 variant not to have a string value")
+	}
+
+	child := tuple.GetChild(2)
+	if !child.GetChild(0).GetBoolean() {
+		t.Error("Expected", true, "got", false)
+	}
+
+	array := child.GetChild(1)
+	if n := array.NChildren(); n != 2 {
+		t.Fatal("Expected", 2, "got", n)
+	}
+	for i, expected := range []string{"a", "b"} {
+		if s, _ := array.GetChild(uint(i)).StringValue(); s != expected {
+			t.Error("Expected", expected, "got", s)
+		}
+	}
+	if array.GetChild(2) != nil {
+		t.Error("Expected no child out of range")
+	}
+
+	if unit := glib.NewVariantTuple(nil); unit.TypeString() != "()" {
+		t.Error("Expected", "()", "got", unit.TypeString())
+	}
+	if empty := glib.NewVariantArray(glib.VARIANT_TYPE_STRING, nil); empty.NChildren() != 0 {
+		t.Error("Expected", 0, "got", empty.NChildren())
+	}
+}
+
+func TestVariantParsePrint(t *testing.T) {
+	testCases := []struct {
+		desc       string
+		text       string
+		typeString string
+		printed    string
+	}{
+		{desc: "tuple", text: "('foo', 42)", typeString: "(si)", printed: "('foo', 42)"},
+		{desc: "annotated array", text: "@as ['a','b']", typeString: "as", printed: "['a', 'b']"},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			variant, err := glib.VariantParse(nil, tC.text)
+			if err != nil {
+				t.Fatal("Unexpected error:", err)
+			}
+			if ts := variant.TypeString(); ts != tC.typeString {
+				t.Error("Expected", tC.typeString, "got", ts)
+			}
+			if printed := variant.Print(false); printed != tC.printed {
+				t.Error("Expected", tC.printed, "got", printed)
+			}
+
+			// The annotated text must parse back to the same variant.
+			again, err := glib.VariantParse(nil, variant.Print(true))
+			if err != nil {
+				t.Fatal("Unexpected error:", err)
+			}
+			if again.String() != variant.String() || again.TypeString() != tC.typeString {
+				t.Error("Expected", variant, "got", again)
+			}
+		})
+	}
+
+	t.Run("malformed", func(t *testing.T) {
+		_, err := glib.VariantParse(nil, "('foo', ")
+		if err == nil {
+			t.Fatal("Expected error for malformed text")
+		}
+		// The error points at the position of the error in the text.
+		if !strings.Contains(err.Error(), "('foo',") {
+			t.Error("Expected the error to show the text, got", err)
+		}
+	})
+}
+
+func TestVariantParseGError(t *testing.T) {
+	_, err := glib.VariantParse(nil, "[1, 'two']")
+
+	var gerr *glib.GError
+	if !errors.As(err, &gerr) {
+		t.Fatal("Expected a *glib.GError, got", err)
+	}
+	if gerr.Domain() != "g-variant-parse-error-quark" {
+		t.Error("Expected", "g-variant-parse-error-quark", "got", gerr.Domain())
+	}
+}
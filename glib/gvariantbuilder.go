@@ -10,7 +10,13 @@ package glib
 // #include "glib.go.h"
 // #include "gvariant.go.h"
 import "C"
-import "unsafe"
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"strings"
+	"unsafe"
+)
 
 /*
  * GVariantBuilder
@@ -19,6 +25,12 @@ import "unsafe"
 // VariantBuilder is a representation of GLib's VariantBuilder.
 type VariantBuilder struct {
 	GVariantBuilder *C.GVariantBuilder
+
+	// signature is the type string of the container for builders created by
+	// NewVariantForSignature, which check the children added against it.
+	signature string
+	added     int
+	ended     bool
 }
 
 func (v *VariantBuilder) toGVariantBuilder() *C.GVariantBuilder {
@@ -50,3 +62,116 @@ func (v *VariantBuilder) native() *C.GVariantBuilder {
 func (v *VariantBuilder) Native() uintptr {
 	return uintptr(unsafe.Pointer(v.native()))
 }
+
+// NewVariantForSignature is a wrapper around g_variant_builder_new(). It
+// creates a builder for a container of the given D-Bus signature, which checks
+// that every child added is of the type that the signature expects next. A
+// signature that isn't a single container type, such as "si", is treated as a
+// tuple of its types, like the arguments of a D-Bus method call. Nil is
+// returned if the signature is invalid or not definite.
+func NewVariantForSignature(signature string) *VariantBuilder {
+	if !isVariantContainerSignature(signature) {
+		signature = "(" + signature + ")"
+	}
+	if !VariantTypeStringIsValid(signature) || strings.ContainsAny(signature, "*?r") {
+		return nil
+	}
+
+	t := VariantTypeNew(signature)
+	c := C.g_variant_builder_new(t.native())
+	runtime.KeepAlive(t)
+
+	v := &VariantBuilder{GVariantBuilder: c, signature: signature}
+	runtime.SetFinalizer(v, (*VariantBuilder).unref)
+	return v
+}
+
+// isVariantContainerSignature returns true if signature is a single complete
+// container type.
+func isVariantContainerSignature(signature string) bool {
+	if signature == "" || !VariantTypeStringIsValid(signature) {
+		return false
+	}
+
+	switch signature[0] {
+	case 'a', 'm', '(', '{':
+		return variantTypeLen(signature) == len(signature)
+	default:
+		return false
+	}
+}
+
+func (v *VariantBuilder) unref() {
+	C.g_variant_builder_unref(v.native())
+}
+
+// nextChildType returns the type string of the child expected after n
+// children were added to a container of the given type, or false if the
+// container can't hold another child.
+func nextChildType(container string, n int) (string, bool) {
+	switch container[0] {
+	case 'a':
+		return container[1:], true
+	case 'm':
+		return container[1:], n == 0
+	}
+
+	// Tuples and dictionary entries.
+	rest := container[1 : len(container)-1]
+	for ; n > 0 && rest != ""; n-- {
+		rest = rest[variantTypeLen(rest):]
+	}
+	if rest == "" {
+		return "", false
+	}
+	return rest[:variantTypeLen(rest)], true
+}
+
+// Add is a wrapper around g_variant_builder_add_value(). For builders created
+// by NewVariantForSignature, an error is returned and child isn't added if the
+// signature doesn't expect a child of its type next.
+func (v *VariantBuilder) Add(child *Variant) error {
+	if v.signature != "" {
+		if v.ended {
+			return errors.New("variant builder has already ended")
+		}
+
+		expected, ok := nextChildType(v.signature, v.added)
+		if !ok {
+			return fmt.Errorf("too many children for variant type %s", v.signature)
+		}
+		if childType := child.TypeString(); childType != expected {
+			return fmt.Errorf("expected child %d of variant type %s to be %s, got %s",
+				v.added, v.signature, expected, childType)
+		}
+
+		v.added++
+	}
+
+	C.g_variant_builder_add_value(v.native(), child.native())
+	runtime.KeepAlive(child)
+	return nil
+}
+
+// End is a wrapper around g_variant_builder_end(). For builders created by
+// NewVariantForSignature, an error is returned if children that the signature
+// requires are missing.
+func (v *VariantBuilder) End() (*Variant, error) {
+	if v.signature != "" {
+		if v.ended {
+			return nil, errors.New("variant builder has already ended")
+		}
+
+		switch v.signature[0] {
+		case '(', '{':
+			if expected, ok := nextChildType(v.signature, v.added); ok {
+				return nil, fmt.Errorf("missing child %d of variant type %s, expected %s",
+					v.added, v.signature, expected)
+			}
+		}
+
+		v.ended = true
+	}
+
+	return takeVariant(C.g_variant_builder_end(v.native())), nil
+}
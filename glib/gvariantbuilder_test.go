@@ -0,0 +1,105 @@
+// Same copyright and license as the rest of the files in this project
+
+package glib_test
+
+import (
+	"testing"
+
+	"github.com/diamondburned/go-glib/glib"
+)
+
+func TestVariantForSignature(t *testing.T) {
+	b := glib.NewVariantForSignature("(si)")
+	if b == nil {
+		t.Fatal("Expected a builder for a valid signature")
+	}
+
+	if err := b.Add(glib.VariantFromString("answer")); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if err := b.Add(glib.VariantFromInt32(42)); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+
+	v, err := b.End()
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if s := v.String(); s != "('answer', 42)" {
+		t.Error("Expected", "('answer', 42)", "got", s)
+	}
+}
+
+func TestVariantForSignatureMismatch(t *testing.T) {
+	testCases := []struct {
+		desc      string
+		signature string
+		children  []*glib.Variant
+		addErr    bool
+		endErr    bool
+	}{
+		{
+			desc:      "wrong type",
+			signature: "(si)",
+			children:  []*glib.Variant{glib.VariantFromInt32(42)},
+			addErr:    true,
+		},
+		{
+			desc:      "too many",
+			signature: "(si)",
+			children: []*glib.Variant{
+				glib.VariantFromString("answer"),
+				glib.VariantFromInt32(42),
+				glib.VariantFromInt32(43),
+			},
+			addErr: true,
+		},
+		{
+			desc:      "missing",
+			signature: "(si)",
+			children:  []*glib.Variant{glib.VariantFromString("answer")},
+			endErr:    true,
+		},
+		{
+			desc:      "bare arguments",
+			signature: "si",
+			children:  []*glib.Variant{glib.VariantFromString("answer")},
+			endErr:    true,
+		},
+		{
+			desc:      "array",
+			signature: "as",
+			children:  []*glib.Variant{glib.VariantFromString("a"), glib.VariantFromInt32(1)},
+			addErr:    true,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			b := glib.NewVariantForSignature(tC.signature)
+			if b == nil {
+				t.Fatal("Expected a builder for", tC.signature)
+			}
+
+			var addErr error
+			for _, child := range tC.children {
+				if addErr = b.Add(child); addErr != nil {
+					break
+				}
+			}
+			if (addErr != nil) != tC.addErr {
+				t.Error("Expected add error", tC.addErr, "got", addErr)
+			}
+			if tC.addErr {
+				return
+			}
+
+			if _, err := b.End(); (err != nil) != tC.endErr {
+				t.Error("Expected end error", tC.endErr, "got", err)
+			}
+		})
+	}
+
+	if b := glib.NewVariantForSignature("(s"); b != nil {
+		t.Error("Expected no builder for an invalid signature")
+	}
+}
@@ -50,3 +50,20 @@ func (v *VariantDict) native() *C.GVariantDict {
 func (v *VariantDict) Native() uintptr {
 	return uintptr(unsafe.Pointer(v.native()))
 }
+
+// MergeVariantDicts merges two "a{sv}" dictionaries into a new one. Keys in
+// override take precedence over the same keys in base, regardless of the types
+// of their values. Keys only present in either dictionary are kept as-is.
+//
+// Either dictionary may be nil, in which case it's treated as empty. It panics
+// if a non-nil dictionary is not of type "a{sv}".
+func MergeVariantDicts(base, override *Variant) *Variant {
+	for _, dict := range [2]*Variant{base, override} {
+		if dict != nil && !dict.IsType(VARIANT_TYPE_VARDICT) {
+			panic("MergeVariantDicts: expected a{sv}, got " + dict.TypeString())
+		}
+	}
+
+	c := C._g_variant_merge_dicts(base.native(), override.native())
+	return takeVariant(c)
+}
@@ -0,0 +1,55 @@
+package glib
+
+// #include <glib.h>
+// #include <glib-object.h>
+// #include "glib.go.h"
+import "C"
+
+import (
+	"runtime"
+	"unsafe"
+)
+
+/*
+ * GWeakRef
+ */
+
+// WeakRef is a representation of GLib's GWeakRef. It references an object
+// without keeping it alive, so it can be held by closures connected to the
+// object or stored anywhere else that would otherwise create a reference
+// cycle. It's safe to use from multiple goroutines.
+type WeakRef struct {
+	weakRef *C.GWeakRef
+}
+
+// NewWeakRef is a wrapper around g_weak_ref_init(). It returns a WeakRef to
+// obj, which is cleared once the WeakRef is garbage collected.
+func NewWeakRef(obj *Object) *WeakRef {
+	weakRef := (*C.GWeakRef)(C.g_malloc0(C.sizeof_GWeakRef))
+	C.g_weak_ref_init(weakRef, C.gpointer(obj.native()))
+
+	w := &WeakRef{weakRef: weakRef}
+	runtime.SetFinalizer(w, (*WeakRef).free)
+	return w
+}
+
+func (w *WeakRef) free() {
+	C.g_weak_ref_clear(w.weakRef)
+	C.g_free(C.gpointer(w.weakRef))
+}
+
+// Get is a wrapper around g_weak_ref_get(). It returns the object, which is
+// kept alive as long as the returned Object is, or nil if the object has
+// already been finalized.
+func (w *WeakRef) Get() *Object {
+	obj := C.g_weak_ref_get(w.weakRef)
+	runtime.KeepAlive(w)
+	return AssumeOwnership(unsafe.Pointer(obj))
+}
+
+// Set is a wrapper around g_weak_ref_set(). It changes the object that w
+// references; obj may be nil to clear it.
+func (w *WeakRef) Set(obj *Object) {
+	C.g_weak_ref_set(w.weakRef, C.gpointer(obj.native()))
+	runtime.KeepAlive(w)
+}
@@ -0,0 +1,78 @@
+// Same copyright and license as the rest of the files in this project
+
+package glib_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/diamondburned/go-glib/glib"
+	"github.com/diamondburned/go-glib/glib/internal/testobject"
+)
+
+func TestWeakRef(t *testing.T) {
+	obj := newTestObject()
+	w := glib.NewWeakRef(obj)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if got := w.Get(); got == nil || got.Native() != obj.Native() {
+				t.Error("Expected", obj.Native(), "got", got)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if !glib.WaitForFinalization(obj, 5*time.Second) {
+		t.Fatal("Expected the weak reference not to keep the object alive")
+	}
+
+	if got := w.Get(); got != nil {
+		t.Error("Expected nil after finalization, got", got)
+	}
+}
+
+func TestWeakRefSet(t *testing.T) {
+	obj := newTestObject()
+	w := glib.NewWeakRef(nil)
+
+	if got := w.Get(); got != nil {
+		t.Error("Expected nil, got", got)
+	}
+
+	w.Set(obj)
+	if got := w.Get(); got == nil || got.Native() != obj.Native() {
+		t.Error("Expected", obj.Native(), "got", got)
+	}
+
+	w.Set(nil)
+	if got := w.Get(); got != nil {
+		t.Error("Expected nil after clearing, got", got)
+	}
+}
+
+func TestWeakRefFloating(t *testing.T) {
+	obj := glib.Take(testobject.NewFloating())
+	w := glib.NewWeakRef(obj)
+
+	// The reference returned by g_weak_ref_get is a full one, so getting the
+	// object must leave the floating reference to its owner.
+	if got := w.Get(); got == nil || got.Native() != obj.Native() {
+		t.Fatal("Expected", obj, "got", got)
+	}
+	if !obj.IsFloating() {
+		t.Error("Expected the floating reference to be left alone")
+	}
+
+	// Release the floating reference like its owner would.
+	obj.RefSink()
+	obj.Unref()
+
+	if !glib.WaitForFinalization(obj, 5*time.Second) {
+		t.Error("Expected the object to be finalized")
+	}
+}
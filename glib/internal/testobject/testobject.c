@@ -0,0 +1,293 @@
+// Same copyright and license as the rest of the files in this project
+
+#include "testobject.h"
+
+GType go_glib_test_mode_get_type(void) {
+  static gsize type_id = 0;
+
+  if (g_once_init_enter(&type_id)) {
+    static const GEnumValue values[] = {
+        {GO_GLIB_TEST_MODE_NONE, "GO_GLIB_TEST_MODE_NONE", "none"},
+        {GO_GLIB_TEST_MODE_FAST, "GO_GLIB_TEST_MODE_FAST", "fast"},
+        {GO_GLIB_TEST_MODE_SLOW, "GO_GLIB_TEST_MODE_SLOW", "slow"},
+        {0, NULL, NULL},
+    };
+    GType id = g_enum_register_static("GoGlibTestMode", values);
+    g_once_init_leave(&type_id, id);
+  }
+
+  return type_id;
+}
+
+GType go_glib_test_flags_get_type(void) {
+  static gsize type_id = 0;
+
+  if (g_once_init_enter(&type_id)) {
+    static const GFlagsValue values[] = {
+        {GO_GLIB_TEST_FLAGS_NONE, "GO_GLIB_TEST_FLAGS_NONE", "none"},
+        {GO_GLIB_TEST_FLAGS_READ, "GO_GLIB_TEST_FLAGS_READ", "read"},
+        {GO_GLIB_TEST_FLAGS_WRITE, "GO_GLIB_TEST_FLAGS_WRITE", "write"},
+        {0, NULL, NULL},
+    };
+    GType id = g_flags_register_static("GoGlibTestFlags", values);
+    g_once_init_leave(&type_id, id);
+  }
+
+  return type_id;
+}
+
+struct _GoGlibTestObject {
+  GObject parent_instance;
+
+  gint int_value;
+  gchar *string_value;
+  gboolean boolean_value;
+  gdouble double_value;
+  GoGlibTestMode mode;
+};
+
+G_DEFINE_TYPE(GoGlibTestObject, go_glib_test_object, G_TYPE_OBJECT)
+
+enum {
+  PROP_0,
+  PROP_INT,
+  PROP_STRING,
+  PROP_BOOLEAN,
+  PROP_DOUBLE,
+  PROP_MODE,
+  N_PROPERTIES,
+};
+
+static GParamSpec *properties[N_PROPERTIES];
+
+enum {
+  SIGNAL_NO_ARGS,
+  SIGNAL_INT,
+  SIGNAL_INT_STRING,
+  SIGNAL_INT_STRING_BOOLEAN,
+  SIGNAL_ENUM,
+  SIGNAL_OBJECTS,
+  SIGNAL_HANDLED,
+  SIGNAL_COUNT,
+  SIGNAL_FIRST,
+  SIGNAL_FIRST_OBJECT,
+  N_SIGNALS,
+};
+
+static guint signals[N_SIGNALS];
+
+// Stop the emission at the first handler returning a non-NULL object, which is
+// then the return value.
+static gboolean first_object_accumulator(GSignalInvocationHint *ihint,
+                                         GValue *return_accu,
+                                         const GValue *handler_return,
+                                         gpointer data) {
+  GObject *object = g_value_get_object(handler_return);
+  if (object == NULL) {
+    return TRUE;
+  }
+
+  g_value_set_object(return_accu, object);
+  return FALSE;
+}
+
+static void go_glib_test_object_set_property(GObject *object, guint prop_id,
+                                             const GValue *value,
+                                             GParamSpec *pspec) {
+  GoGlibTestObject *self = GO_GLIB_TEST_OBJECT(object);
+
+  switch (prop_id) {
+  case PROP_INT:
+    self->int_value = g_value_get_int(value);
+    break;
+  case PROP_STRING:
+    g_free(self->string_value);
+    self->string_value = g_value_dup_string(value);
+    break;
+  case PROP_BOOLEAN:
+    self->boolean_value = g_value_get_boolean(value);
+    break;
+  case PROP_DOUBLE:
+    self->double_value = g_value_get_double(value);
+    break;
+  case PROP_MODE:
+    self->mode = g_value_get_enum(value);
+    break;
+  default:
+    G_OBJECT_WARN_INVALID_PROPERTY_ID(object, prop_id, pspec);
+  }
+}
+
+static void go_glib_test_object_get_property(GObject *object, guint prop_id,
+                                             GValue *value,
+                                             GParamSpec *pspec) {
+  GoGlibTestObject *self = GO_GLIB_TEST_OBJECT(object);
+
+  switch (prop_id) {
+  case PROP_INT:
+    g_value_set_int(value, self->int_value);
+    break;
+  case PROP_STRING:
+    g_value_set_string(value, self->string_value);
+    break;
+  case PROP_BOOLEAN:
+    g_value_set_boolean(value, self->boolean_value);
+    break;
+  case PROP_DOUBLE:
+    g_value_set_double(value, self->double_value);
+    break;
+  case PROP_MODE:
+    g_value_set_enum(value, self->mode);
+    break;
+  default:
+    G_OBJECT_WARN_INVALID_PROPERTY_ID(object, prop_id, pspec);
+  }
+}
+
+static void go_glib_test_object_finalize(GObject *object) {
+  GoGlibTestObject *self = GO_GLIB_TEST_OBJECT(object);
+
+  g_free(self->string_value);
+
+  G_OBJECT_CLASS(go_glib_test_object_parent_class)->finalize(object);
+}
+
+static void go_glib_test_object_class_init(GoGlibTestObjectClass *klass) {
+  GObjectClass *object_class = G_OBJECT_CLASS(klass);
+  GType type = G_TYPE_FROM_CLASS(klass);
+
+  object_class->set_property = go_glib_test_object_set_property;
+  object_class->get_property = go_glib_test_object_get_property;
+  object_class->finalize = go_glib_test_object_finalize;
+
+  properties[PROP_INT] =
+      g_param_spec_int("int", "Int", "An integer", G_MININT, G_MAXINT, 0,
+                       G_PARAM_READWRITE | G_PARAM_STATIC_STRINGS);
+  properties[PROP_STRING] =
+      g_param_spec_string("string", "String", "A string", NULL,
+                          G_PARAM_READWRITE | G_PARAM_STATIC_STRINGS);
+  properties[PROP_BOOLEAN] =
+      g_param_spec_boolean("boolean", "Boolean", "A boolean", FALSE,
+                           G_PARAM_READWRITE | G_PARAM_STATIC_STRINGS);
+  properties[PROP_DOUBLE] = g_param_spec_double(
+      "double", "Double", "A double", -G_MAXDOUBLE, G_MAXDOUBLE, 0,
+      G_PARAM_READWRITE | G_PARAM_STATIC_STRINGS);
+  properties[PROP_MODE] = g_param_spec_enum(
+      "mode", "Mode", "A mode", GO_GLIB_TYPE_TEST_MODE, GO_GLIB_TEST_MODE_NONE,
+      G_PARAM_READWRITE | G_PARAM_STATIC_STRINGS);
+
+  g_object_class_install_properties(object_class, N_PROPERTIES, properties);
+
+  signals[SIGNAL_NO_ARGS] =
+      g_signal_new("no-args", type, G_SIGNAL_RUN_LAST, 0, NULL, NULL, NULL,
+                   G_TYPE_NONE, 0);
+  signals[SIGNAL_INT] = g_signal_new("int", type, G_SIGNAL_RUN_LAST, 0, NULL,
+                                     NULL, NULL, G_TYPE_NONE, 1, G_TYPE_INT);
+  signals[SIGNAL_INT_STRING] =
+      g_signal_new("int-string", type, G_SIGNAL_RUN_LAST, 0, NULL, NULL, NULL,
+                   G_TYPE_NONE, 2, G_TYPE_INT, G_TYPE_STRING);
+  signals[SIGNAL_INT_STRING_BOOLEAN] = g_signal_new(
+      "int-string-boolean", type, G_SIGNAL_RUN_LAST, 0, NULL, NULL, NULL,
+      G_TYPE_NONE, 3, G_TYPE_INT, G_TYPE_STRING, G_TYPE_BOOLEAN);
+  signals[SIGNAL_ENUM] =
+      g_signal_new("enum", type, G_SIGNAL_RUN_LAST, 0, NULL, NULL, NULL,
+                   G_TYPE_NONE, 1, GO_GLIB_TYPE_TEST_MODE);
+  signals[SIGNAL_OBJECTS] =
+      g_signal_new("objects", type, G_SIGNAL_RUN_LAST, 0, NULL, NULL, NULL,
+                   G_TYPE_NONE, 1, G_TYPE_PTR_ARRAY);
+  signals[SIGNAL_HANDLED] =
+      g_signal_new("handled", type, G_SIGNAL_RUN_LAST, 0,
+                   g_signal_accumulator_true_handled, NULL, NULL,
+                   G_TYPE_BOOLEAN, 0);
+  signals[SIGNAL_COUNT] =
+      g_signal_new("count", type, G_SIGNAL_RUN_LAST, 0, NULL, NULL, NULL,
+                   G_TYPE_UINT, 0);
+  signals[SIGNAL_FIRST] =
+      g_signal_new("first", type, G_SIGNAL_RUN_LAST, 0,
+                   g_signal_accumulator_first_wins, NULL, NULL,
+                   G_TYPE_STRING, 0);
+  signals[SIGNAL_FIRST_OBJECT] =
+      g_signal_new("first-object", type, G_SIGNAL_RUN_LAST, 0,
+                   first_object_accumulator, NULL, NULL, G_TYPE_OBJECT, 0);
+}
+
+static void go_glib_test_object_init(GoGlibTestObject *self) {}
+
+void go_glib_test_object_emit_no_args(GoGlibTestObject *self) {
+  g_signal_emit(self, signals[SIGNAL_NO_ARGS], 0);
+}
+
+void go_glib_test_object_emit_int(GoGlibTestObject *self, gint i) {
+  g_signal_emit(self, signals[SIGNAL_INT], 0, i);
+}
+
+void go_glib_test_object_emit_int_string(GoGlibTestObject *self, gint i,
+                                         const gchar *s) {
+  g_signal_emit(self, signals[SIGNAL_INT_STRING], 0, i, s);
+}
+
+void go_glib_test_object_emit_int_string_boolean(GoGlibTestObject *self,
+                                                 gint i, const gchar *s,
+                                                 gboolean b) {
+  g_signal_emit(self, signals[SIGNAL_INT_STRING_BOOLEAN], 0, i, s, b);
+}
+
+void go_glib_test_object_emit_enum(GoGlibTestObject *self,
+                                   GoGlibTestMode mode) {
+  g_signal_emit(self, signals[SIGNAL_ENUM], 0, mode);
+}
+
+void go_glib_test_object_emit_objects(GoGlibTestObject *self,
+                                      GObject **objects, guint n) {
+  GPtrArray *array = g_ptr_array_new_with_free_func(g_object_unref);
+  guint i;
+
+  for (i = 0; i < n; i++) {
+    g_ptr_array_add(array, g_object_ref(objects[i]));
+  }
+
+  g_signal_emit(self, signals[SIGNAL_OBJECTS], 0, array);
+  g_ptr_array_unref(array);
+}
+
+gboolean go_glib_test_object_emit_handled(GoGlibTestObject *self) {
+  gboolean handled = FALSE;
+  g_signal_emit(self, signals[SIGNAL_HANDLED], 0, &handled);
+  return handled;
+}
+
+guint go_glib_test_object_emit_count(GoGlibTestObject *self) {
+  guint count = 0;
+  g_signal_emit(self, signals[SIGNAL_COUNT], 0, &count);
+  return count;
+}
+
+GObject *go_glib_test_new_floating(void) {
+  return g_object_new(G_TYPE_INITIALLY_UNOWNED, NULL);
+}
+
+guint go_glib_test_ref_count(GObject *object) {
+  return g_atomic_int_get(&object->ref_count);
+}
+
+static gint criticals;
+
+static void go_glib_test_count_critical(const gchar *log_domain,
+                                        GLogLevelFlags log_level,
+                                        const gchar *message,
+                                        gpointer user_data) {
+  g_atomic_int_inc(&criticals);
+}
+
+guint go_glib_test_watch_criticals(void) {
+  g_atomic_int_set(&criticals, 0);
+  return g_log_set_handler("GLib-GObject",
+                           G_LOG_LEVEL_CRITICAL | G_LOG_FLAG_FATAL |
+                               G_LOG_FLAG_RECURSION,
+                           go_glib_test_count_critical, NULL);
+}
+
+guint go_glib_test_unwatch_criticals(guint handler) {
+  g_log_remove_handler("GLib-GObject", handler);
+  return g_atomic_int_get(&criticals);
+}
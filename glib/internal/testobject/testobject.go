@@ -0,0 +1,207 @@
+// Package testobject provides a GObject type implemented in C, which the glib
+// package's tests use to exercise signals and properties that plain GObjects
+// don't have.
+//
+// GoGlibTestObject has the following read-write properties, all of which
+// default to their zero values:
+//
+//	int:     gint
+//	string:  gchararray
+//	boolean: gboolean
+//	double:  gdouble
+//	mode:    GoGlibTestMode
+//
+// It has the following signals:
+//
+//	no-args:            void ()
+//	int:                void (gint)
+//	int-string:         void (gint, const gchar*)
+//	int-string-boolean: void (gint, const gchar*, gboolean)
+//	enum:               void (GoGlibTestMode)
+//	objects:            void (GPtrArray* of GObject*)
+//	handled:            gboolean (), stopped by the first handler returning TRUE
+//	count:              guint ()
+//	first:              gchararray (), stopped by the first handler
+//	first-object:       GObject* (), stopped by the first handler returning
+//	                    a non-NULL object
+//
+// GoGlibTestMode is an enum type with the values none (0), fast (1) and slow
+// (2). GoGlibTestFlags is a flags type with the values read (1) and write
+// (2).
+//
+// NewError creates GErrors and NewStrv GStrv boxed values, which the glib
+// package can't do from its tests. NewFloating and RefCount help testing
+// reference counting, and CountCriticals catches misuses of GObject.
+package testobject
+
+// #cgo pkg-config: gobject-2.0
+// #include <stdlib.h>
+// #include "testobject.h"
+import "C"
+
+import "unsafe"
+
+// Type returns the GType of GoGlibTestObject.
+func Type() uint {
+	return uint(C.go_glib_test_object_get_type())
+}
+
+// ModeType returns the GType of GoGlibTestMode.
+func ModeType() uint {
+	return uint(C.go_glib_test_mode_get_type())
+}
+
+// Mode values of GoGlibTestMode.
+const (
+	ModeNone int = C.GO_GLIB_TEST_MODE_NONE
+	ModeFast int = C.GO_GLIB_TEST_MODE_FAST
+	ModeSlow int = C.GO_GLIB_TEST_MODE_SLOW
+)
+
+// FlagsType returns the GType of GoGlibTestFlags.
+func FlagsType() uint {
+	return uint(C.go_glib_test_flags_get_type())
+}
+
+// Flags values of GoGlibTestFlags.
+const (
+	FlagsRead  uint = C.GO_GLIB_TEST_FLAGS_READ
+	FlagsWrite uint = C.GO_GLIB_TEST_FLAGS_WRITE
+)
+
+func native(obj uintptr) *C.GoGlibTestObject {
+	return (*C.GoGlibTestObject)(unsafe.Pointer(obj))
+}
+
+// EmitNoArgs emits the no-args signal on the given GoGlibTestObject pointer
+// directly from C.
+func EmitNoArgs(obj uintptr) {
+	C.go_glib_test_object_emit_no_args(native(obj))
+}
+
+// EmitInt emits the int signal on the given GoGlibTestObject pointer directly
+// from C.
+func EmitInt(obj uintptr, i int) {
+	C.go_glib_test_object_emit_int(native(obj), C.gint(i))
+}
+
+// EmitIntString emits the int-string signal on the given GoGlibTestObject
+// pointer directly from C.
+func EmitIntString(obj uintptr, i int, s string) {
+	cstr := C.CString(s)
+	defer C.free(unsafe.Pointer(cstr))
+
+	C.go_glib_test_object_emit_int_string(native(obj), C.gint(i), (*C.gchar)(cstr))
+}
+
+// EmitIntStringBoolean emits the int-string-boolean signal on the given
+// GoGlibTestObject pointer directly from C.
+func EmitIntStringBoolean(obj uintptr, i int, s string, b bool) {
+	cstr := C.CString(s)
+	defer C.free(unsafe.Pointer(cstr))
+
+	var cb C.gboolean
+	if b {
+		cb = C.TRUE
+	}
+
+	C.go_glib_test_object_emit_int_string_boolean(native(obj), C.gint(i), (*C.gchar)(cstr), cb)
+}
+
+// EmitEnum emits the enum signal on the given GoGlibTestObject pointer directly
+// from C.
+func EmitEnum(obj uintptr, mode int) {
+	C.go_glib_test_object_emit_enum(native(obj), C.GoGlibTestMode(mode))
+}
+
+// EmitObjects emits the objects signal on the given GoGlibTestObject pointer
+// directly from C. The given GObject pointers are passed in a GPtrArray.
+func EmitObjects(obj uintptr, objects []uintptr) {
+	cobjs := make([]*C.GObject, len(objects))
+	for i, o := range objects {
+		cobjs[i] = (*C.GObject)(unsafe.Pointer(o))
+	}
+
+	var ptr **C.GObject
+	if len(cobjs) > 0 {
+		ptr = &cobjs[0]
+	}
+
+	C.go_glib_test_object_emit_objects(native(obj), ptr, C.guint(len(cobjs)))
+}
+
+// EmitHandled emits the handled signal on the given GoGlibTestObject pointer
+// directly from C and returns its return value.
+func EmitHandled(obj uintptr) bool {
+	return C.go_glib_test_object_emit_handled(native(obj)) != C.FALSE
+}
+
+// EmitCount emits the count signal on the given GoGlibTestObject pointer
+// directly from C and returns its return value.
+func EmitCount(obj uintptr) uint {
+	return uint(C.go_glib_test_object_emit_count(native(obj)))
+}
+
+// NewError creates a new GError with the given domain, as the string of its
+// quark, code and message. Ownership of the GError is given to the caller.
+func NewError(domain string, code int, message string) unsafe.Pointer {
+	cdomain := C.CString(domain)
+	defer C.free(unsafe.Pointer(cdomain))
+
+	cmessage := C.CString(message)
+	defer C.free(unsafe.Pointer(cmessage))
+
+	gerr := C.g_error_new_literal(C.g_quark_from_string(cdomain), C.gint(code), cmessage)
+	return unsafe.Pointer(gerr)
+}
+
+// StrvType returns the GType of GStrv, which is a boxed type.
+func StrvType() uint {
+	return uint(C.g_strv_get_type())
+}
+
+// NewStrv creates a new GStrv holding the strings of s separated by commas.
+// It must be freed using FreeStrv.
+func NewStrv(s string) unsafe.Pointer {
+	cstr := C.CString(s)
+	defer C.free(unsafe.Pointer(cstr))
+
+	csep := C.CString(",")
+	defer C.free(unsafe.Pointer(csep))
+
+	return unsafe.Pointer(C.g_strsplit(cstr, csep, -1))
+}
+
+// JoinStrv returns the strings of the given GStrv joined by commas.
+func JoinStrv(strv unsafe.Pointer) string {
+	csep := C.CString(",")
+	defer C.free(unsafe.Pointer(csep))
+
+	cstr := C.g_strjoinv(csep, (**C.gchar)(strv))
+	defer C.g_free(C.gpointer(cstr))
+
+	return C.GoString(cstr)
+}
+
+// FreeStrv frees the given GStrv.
+func FreeStrv(strv unsafe.Pointer) {
+	C.g_strfreev((**C.gchar)(strv))
+}
+
+// NewFloating creates a new GInitiallyUnowned, which has a floating reference.
+func NewFloating() unsafe.Pointer {
+	return unsafe.Pointer(C.go_glib_test_new_floating())
+}
+
+// RefCount returns the reference count of the given GObject pointer.
+func RefCount(obj uintptr) uint {
+	return uint(C.go_glib_test_ref_count((*C.GObject)(unsafe.Pointer(obj))))
+}
+
+// CountCriticals calls f and returns the number of critical warnings that
+// GObject logged meanwhile, which aren't printed.
+func CountCriticals(f func()) uint {
+	handler := C.go_glib_test_watch_criticals()
+	f()
+	return uint(C.go_glib_test_unwatch_criticals(handler))
+}
@@ -0,0 +1,54 @@
+// Same copyright and license as the rest of the files in this project
+
+#ifndef __GO_GLIB_TEST_OBJECT_H__
+#define __GO_GLIB_TEST_OBJECT_H__
+
+#include <glib-object.h>
+
+G_BEGIN_DECLS
+
+typedef enum {
+  GO_GLIB_TEST_MODE_NONE,
+  GO_GLIB_TEST_MODE_FAST,
+  GO_GLIB_TEST_MODE_SLOW,
+} GoGlibTestMode;
+
+#define GO_GLIB_TYPE_TEST_MODE (go_glib_test_mode_get_type())
+GType go_glib_test_mode_get_type(void);
+
+typedef enum {
+  GO_GLIB_TEST_FLAGS_NONE = 0,
+  GO_GLIB_TEST_FLAGS_READ = 1 << 0,
+  GO_GLIB_TEST_FLAGS_WRITE = 1 << 1,
+} GoGlibTestFlags;
+
+#define GO_GLIB_TYPE_TEST_FLAGS (go_glib_test_flags_get_type())
+GType go_glib_test_flags_get_type(void);
+
+#define GO_GLIB_TYPE_TEST_OBJECT (go_glib_test_object_get_type())
+G_DECLARE_FINAL_TYPE(GoGlibTestObject, go_glib_test_object, GO_GLIB,
+                     TEST_OBJECT, GObject)
+
+void go_glib_test_object_emit_no_args(GoGlibTestObject *self);
+void go_glib_test_object_emit_int(GoGlibTestObject *self, gint i);
+void go_glib_test_object_emit_int_string(GoGlibTestObject *self, gint i,
+                                         const gchar *s);
+void go_glib_test_object_emit_enum(GoGlibTestObject *self,
+                                   GoGlibTestMode mode);
+void go_glib_test_object_emit_objects(GoGlibTestObject *self,
+                                      GObject **objects, guint n);
+void go_glib_test_object_emit_int_string_boolean(GoGlibTestObject *self,
+                                                 gint i, const gchar *s,
+                                                 gboolean b);
+gboolean go_glib_test_object_emit_handled(GoGlibTestObject *self);
+guint go_glib_test_object_emit_count(GoGlibTestObject *self);
+
+GObject *go_glib_test_new_floating(void);
+guint go_glib_test_ref_count(GObject *object);
+
+guint go_glib_test_watch_criticals(void);
+guint go_glib_test_unwatch_criticals(guint handler);
+
+G_END_DECLS
+
+#endif
@@ -0,0 +1,180 @@
+package glib
+
+// #include <glib.h>
+// #include <glib-object.h>
+// #include "glib.go.h"
+import "C"
+import "unsafe"
+
+// List is a representation of Glib's GList. A List must be manually freed by
+// either calling Free() or FreeFull() if the caller owns it. A nil *List is an
+// empty list.
+type List struct {
+	list *C.struct__GList
+	// If set, dataWrap is called every time Data() or NthData() is called to
+	// wrap the raw underlying value into the appropriate type.
+	dataWrap func(unsafe.Pointer) interface{}
+}
+
+// WrapList wraps the GList at the given address. Nil is returned for an empty
+// list.
+func WrapList(obj uintptr) *List {
+	return wrapList((*C.struct__GList)(unsafe.Pointer(obj)))
+}
+
+func wrapList(obj *C.struct__GList) *List {
+	if obj == nil {
+		return nil
+	}
+
+	//NOTE a list should be freed by calling either
+	//g_list_free() or g_list_free_full(). However, it's not possible to use a
+	//finalizer for this, since only the caller knows whether it owns the list.
+	return &List{list: obj}
+}
+
+func (v *List) wrapNewHead(obj *C.struct__GList) *List {
+	if obj == nil {
+		return nil
+	}
+	l := &List{list: obj}
+	if v != nil {
+		l.dataWrap = v.dataWrap
+	}
+	return l
+}
+
+func (v *List) Native() uintptr {
+	return uintptr(unsafe.Pointer(v.native()))
+}
+
+func (v *List) native() *C.struct__GList {
+	if v == nil || v.list == nil {
+		return nil
+	}
+	return v.list
+}
+
+// DataWrapper sets the wrap function, which is called during NthData() and
+// Data(). It's used to cast raw C data into appropriate Go structures and
+// types every time that data is retrieved, such as with ObjectDataWrapper.
+func (v *List) DataWrapper(fn func(unsafe.Pointer) interface{}) {
+	if v == nil {
+		return
+	}
+	v.dataWrap = fn
+}
+
+// ObjectDataWrapper is a data wrapper for lists of GObjects, which wraps each
+// element into an *Object.
+func ObjectDataWrapper(ptr unsafe.Pointer) interface{} {
+	return Take(ptr)
+}
+
+// Append is a wrapper around g_list_append(). The returned list is the new
+// head of the list, which is only different from v if v is empty.
+func (v *List) Append(data uintptr) *List {
+	ret := C.g_list_append(v.native(), C.gpointer(data))
+	if ret == v.native() {
+		return v
+	}
+
+	return v.wrapNewHead(ret)
+}
+
+// Prepend is a wrapper around g_list_prepend(). The returned list is the new
+// head of the list, which must be reassigned.
+func (v *List) Prepend(data uintptr) *List {
+	return v.wrapNewHead(C.g_list_prepend(v.native(), C.gpointer(data)))
+}
+
+// Length is a wrapper around g_list_length().
+func (v *List) Length() uint {
+	return uint(C.g_list_length(v.native()))
+}
+
+// Next is a wrapper around the next struct field.
+func (v *List) Next() *List {
+	n := v.native()
+	if n == nil {
+		return nil
+	}
+
+	return v.wrapNewHead(n.next)
+}
+
+// Previous is a wrapper around the prev struct field.
+func (v *List) Previous() *List {
+	n := v.native()
+	if n == nil {
+		return nil
+	}
+
+	return v.wrapNewHead(n.prev)
+}
+
+// Nth is a wrapper around g_list_nth_data(). Nil is returned if n is out of
+// range.
+func (v *List) Nth(n uint) unsafe.Pointer {
+	return unsafe.Pointer(C.g_list_nth_data(v.native(), C.guint(n)))
+}
+
+// NthData acts the same as Nth, but the data is wrapped by the function set
+// with DataWrapper, if any.
+func (v *List) NthData(n uint) interface{} {
+	ptr := v.Nth(n)
+	if v != nil && v.dataWrap != nil {
+		return v.dataWrap(ptr)
+	}
+	return ptr
+}
+
+// DataRaw is a wrapper around the data struct field.
+func (v *List) DataRaw() unsafe.Pointer {
+	n := v.native()
+	if n == nil {
+		return nil
+	}
+	return unsafe.Pointer(n.data)
+}
+
+// Data acts the same as DataRaw, but the data is wrapped by the function set
+// with DataWrapper, if any.
+func (v *List) Data() interface{} {
+	ptr := v.DataRaw()
+	if v != nil && v.dataWrap != nil {
+		return v.dataWrap(ptr)
+	}
+	return ptr
+}
+
+// Foreach acts the same as g_list_foreach(). It calls fn with the data of
+// every element, from the head to the tail.
+// No user_data argument is implemented because of Go closure capabilities.
+func (v *List) Foreach(fn func(item unsafe.Pointer)) {
+	for l := v.native(); l != nil; l = l.next {
+		fn(unsafe.Pointer(l.data))
+	}
+}
+
+// Free is a wrapper around g_list_free(). It frees the elements of the list,
+// but not the data they point to, which is for lists whose data the caller
+// doesn't own. The list must not be used afterwards.
+func (v *List) Free() {
+	if v == nil {
+		return
+	}
+	C.g_list_free(v.list)
+	v.list = nil
+}
+
+// FreeFull acts the same as g_list_free_full(). It calls fn with the data of
+// every element to free it, such as by unreferencing it, and then frees the
+// list like Free.
+func (v *List) FreeFull(fn func(item unsafe.Pointer)) {
+	if v == nil {
+		return
+	}
+	v.Foreach(fn)
+	v.Free()
+}
@@ -0,0 +1,100 @@
+// Same copyright and license as the rest of the files in this project
+
+package glib_test
+
+import (
+	"testing"
+	"unsafe"
+
+	"github.com/diamondburned/go-glib/glib"
+)
+
+func TestListForeach(t *testing.T) {
+	objects := []*glib.Object{newTestObject(), newTestObject(), newTestObject()}
+
+	var list *glib.List
+	for _, obj := range objects {
+		list = list.Append(obj.Native())
+	}
+	defer list.Free()
+
+	if list.Length() != 3 {
+		t.Fatal("Expected", 3, "elements, got", list.Length())
+	}
+
+	var i int
+	list.Foreach(func(item unsafe.Pointer) {
+		if i >= len(objects) {
+			t.Fatal("Expected", len(objects), "elements, got more")
+		}
+		if uintptr(item) != objects[i].Native() {
+			t.Error("Expected", objects[i].Native(), "at", i, "got", item)
+		}
+		i++
+	})
+	if i != len(objects) {
+		t.Error("Expected", len(objects), "elements, got", i)
+	}
+
+	list.DataWrapper(glib.ObjectDataWrapper)
+	for i, obj := range objects {
+		if uintptr(list.Nth(uint(i))) != obj.Native() {
+			t.Error("Expected", obj.Native(), "at", i, "got", list.Nth(uint(i)))
+		}
+		if got := list.NthData(uint(i)).(*glib.Object); got.Native() != obj.Native() {
+			t.Error("Expected", obj, "at", i, "got", got)
+		}
+	}
+}
+
+func TestListEmpty(t *testing.T) {
+	var list *glib.List
+
+	if list.Length() != 0 {
+		t.Error("Expected", 0, "elements, got", list.Length())
+	}
+	list.Foreach(func(unsafe.Pointer) {
+		t.Error("Expected no elements to be visited")
+	})
+	list.Free()
+}
+
+func TestListEmptyAppendPrepend(t *testing.T) {
+	var appended *glib.List
+	appended = appended.Append(1)
+	defer appended.Free()
+
+	if appended == nil || appended.Length() != 1 || uintptr(appended.Nth(0)) != 1 {
+		t.Error("Expected a list of", 1, "element, got", appended)
+	}
+
+	var prepended *glib.List
+	for i := 1; i <= 3; i++ {
+		prepended = prepended.Prepend(uintptr(i))
+	}
+	defer prepended.Free()
+
+	var got []uintptr
+	prepended.Foreach(func(item unsafe.Pointer) {
+		got = append(got, uintptr(item))
+	})
+	if len(got) != 3 || got[0] != 3 || got[1] != 2 || got[2] != 1 {
+		t.Error("Expected", []uintptr{3, 2, 1}, "got", got)
+	}
+}
+
+func TestListFreeFull(t *testing.T) {
+	var list *glib.List
+	for i := 1; i <= 3; i++ {
+		list = list.Append(uintptr(i))
+	}
+
+	var freed []uintptr
+	list.FreeFull(func(item unsafe.Pointer) {
+		freed = append(freed, uintptr(item))
+	})
+
+	if len(freed) != 3 || freed[0] != 1 || freed[1] != 2 || freed[2] != 3 {
+		t.Error("Expected", []uintptr{1, 2, 3}, "got", freed)
+	}
+}
@@ -35,14 +35,15 @@ func (v *SList) wrapNewHead(obj *C.struct__GSList) *SList {
 	if obj == nil {
 		return nil
 	}
-	return &SList{
-		list:     obj,
-		dataWrap: v.dataWrap,
+	l := &SList{list: obj}
+	if v != nil {
+		l.dataWrap = v.dataWrap
 	}
+	return l
 }
 
 func (v *SList) Native() uintptr {
-	return uintptr(unsafe.Pointer(v.list))
+	return uintptr(unsafe.Pointer(v.native()))
 }
 
 func (v *SList) native() *C.struct__GSList {
@@ -52,7 +53,7 @@ func (v *SList) native() *C.struct__GSList {
 	return v.list
 }
 
-// DataWapper sets wrap functions, which is called during NthData()
+// DataWrapper sets wrap functions, which is called during NthData()
 // and Data(). It's used to cast raw C data into appropriate
 // Go structures and types every time that data is retreived.
 func (v *SList) DataWrapper(fn func(unsafe.Pointer) interface{}) {
@@ -62,13 +63,35 @@ func (v *SList) DataWrapper(fn func(unsafe.Pointer) interface{}) {
 	v.dataWrap = fn
 }
 
+// Append is a wrapper around g_slist_append(). The returned list is the new
+// head of the list, which is only different from v if v is empty, so it must
+// always be reassigned. Append walks the whole list to find its tail, so
+// building a list by appending to it is quadratic; prepend the elements and
+// reverse the list instead.
 func (v *SList) Append(data uintptr) *SList {
 	ret := C.g_slist_append(v.native(), C.gpointer(data))
 	if ret == v.native() {
 		return v
 	}
 
-	return wrapSList(ret)
+	return v.wrapNewHead(ret)
+}
+
+// Prepend is a wrapper around g_slist_prepend(). The returned list is the new
+// head of the list, which must be reassigned.
+func (v *SList) Prepend(data uintptr) *SList {
+	return v.wrapNewHead(C.g_slist_prepend(v.native(), C.gpointer(data)))
+}
+
+// Reverse is a wrapper around g_slist_reverse(). The returned list is the new
+// head of the list, which must be reassigned.
+func (v *SList) Reverse() *SList {
+	ret := C.g_slist_reverse(v.native())
+	if ret == v.native() {
+		return v
+	}
+
+	return v.wrapNewHead(ret)
 }
 
 // Length is a wrapper around g_slist_length().
@@ -83,7 +106,23 @@ func (v *SList) Next() *SList {
 		return nil
 	}
 
-	return wrapSList(n.next)
+	return v.wrapNewHead(n.next)
+}
+
+// Nth is a wrapper around g_slist_nth_data(). Nil is returned if n is out of
+// range.
+func (v *SList) Nth(n uint) unsafe.Pointer {
+	return unsafe.Pointer(C.g_slist_nth_data(v.native(), C.guint(n)))
+}
+
+// NthData acts the same as Nth, but the data is wrapped by the function set
+// with DataWrapper, if any.
+func (v *SList) NthData(n uint) interface{} {
+	ptr := v.Nth(n)
+	if v != nil && v.dataWrap != nil {
+		return v.dataWrap(ptr)
+	}
+	return ptr
 }
 
 // dataRaw is a wrapper around the data struct field
@@ -104,11 +143,11 @@ func (v *SList) DataRaw() unsafe.Pointer {
 	return unsafe.Pointer(n.data)
 }
 
-// Data acts the same as data struct field, but it returns raw unsafe.Pointer as interface.
-// TODO: Align with List struct and add member + logic for `dataWrap func(unsafe.Pointer) interface{}`?
+// Data acts the same as DataRaw, but the data is wrapped by the function set
+// with DataWrapper, if any.
 func (v *SList) Data() interface{} {
 	ptr := v.dataRaw()
-	if v.dataWrap != nil {
+	if v != nil && v.dataWrap != nil {
 		return v.dataWrap(ptr)
 	}
 	return ptr
@@ -122,19 +161,31 @@ func (v *SList) Foreach(fn func(item interface{})) {
 	}
 }
 
-// Free is a wrapper around g_slist_free().
+// Free is a wrapper around g_slist_free(). It frees the elements of the list,
+// but not the data they point to, which is for lists whose data the caller
+// doesn't own. The list must not be used afterwards.
 func (v *SList) Free() {
-	C.g_slist_free(v.native())
+	if v == nil {
+		return
+	}
+	C.g_slist_free(v.list)
+	v.list = nil
 }
 
-// FreeFull is a wrapper around g_slist_free_full().
-func (v *SList) FreeFull() {
-	//TODO implement GDestroyNotify callback
-	C.g_slist_free_full(v.native(), nil)
+// FreeFull acts the same as g_slist_free_full(). It calls fn with the data of
+// every element to free it, such as by unreferencing it, and then frees the
+// list like Free.
+func (v *SList) FreeFull(fn func(item unsafe.Pointer)) {
+	if v == nil {
+		return
+	}
+	for l := v.list; l != nil; l = l.next {
+		fn(unsafe.Pointer(l.data))
+	}
+	v.Free()
 }
 
 // GSList * 	g_slist_alloc ()
-// GSList * 	g_slist_prepend ()
 // GSList * 	g_slist_insert ()
 // GSList * 	g_slist_insert_before ()
 // GSList * 	g_slist_insert_sorted ()
@@ -145,14 +196,12 @@ func (v *SList) FreeFull() {
 // void 	g_slist_free_1 ()
 // GSList * 	g_slist_copy ()
 // GSList * 	g_slist_copy_deep ()
-// GSList * 	g_slist_reverse ()
 // GSList * 	g_slist_insert_sorted_with_data ()
 // GSList * 	g_slist_sort ()
 // GSList * 	g_slist_sort_with_data ()
 // GSList * 	g_slist_concat ()
 // GSList * 	g_slist_last ()
 // GSList * 	g_slist_nth ()
-// gpointer 	g_slist_nth_data ()
 // GSList * 	g_slist_find ()
 // GSList * 	g_slist_find_custom ()
 // gint 	g_slist_position ()
@@ -0,0 +1,96 @@
+// Same copyright and license as the rest of the files in this project
+
+package glib_test
+
+import (
+	"testing"
+	"unsafe"
+
+	"github.com/diamondburned/go-glib/glib"
+)
+
+func TestSListPrepend(t *testing.T) {
+	var list *glib.SList
+	for i := 1; i <= 3; i++ {
+		list = list.Prepend(uintptr(i))
+	}
+	defer list.Free()
+
+	if list.Length() != 3 {
+		t.Fatal("Expected", 3, "elements, got", list.Length())
+	}
+
+	var got []uintptr
+	list.Foreach(func(item interface{}) {
+		got = append(got, uintptr(item.(unsafe.Pointer)))
+	})
+	if len(got) != 3 || got[0] != 3 || got[1] != 2 || got[2] != 1 {
+		t.Error("Expected", []uintptr{3, 2, 1}, "got", got)
+	}
+
+	list = list.Reverse()
+	for i := uint(0); i < 3; i++ {
+		if got := uintptr(list.Nth(i)); got != uintptr(i+1) {
+			t.Error("Expected", i+1, "at", i, "got", got)
+		}
+	}
+}
+
+func TestSListAppendEmpty(t *testing.T) {
+	var list *glib.SList
+	list = list.Append(1)
+	defer list.Free()
+
+	if list == nil || list.Length() != 1 {
+		t.Fatal("Expected a list of", 1, "element, got", list)
+	}
+	if got := uintptr(list.Nth(0)); got != 1 {
+		t.Error("Expected", 1, "got", got)
+	}
+}
+
+func TestSListDataWrapper(t *testing.T) {
+	var list *glib.SList
+	for i := 1; i <= 3; i++ {
+		list = list.Append(uintptr(i))
+	}
+	defer list.Free()
+
+	list.DataWrapper(func(ptr unsafe.Pointer) interface{} {
+		return int(uintptr(ptr)) * 10
+	})
+
+	var got []int
+	list.Foreach(func(item interface{}) {
+		got = append(got, item.(int))
+	})
+	if len(got) != 3 || got[0] != 10 || got[1] != 20 || got[2] != 30 {
+		t.Error("Expected", []int{10, 20, 30}, "got", got)
+	}
+
+	if got := list.NthData(1); got != 20 {
+		t.Error("Expected", 20, "got", got)
+	}
+}
+
+func TestSListFreeFull(t *testing.T) {
+	var list *glib.SList
+	for i := 1; i <= 3; i++ {
+		list = list.Prepend(uintptr(i))
+	}
+
+	var freed int
+	list.FreeFull(func(unsafe.Pointer) {
+		freed++
+	})
+
+	if freed != 3 {
+		t.Error("Expected", 3, "freed elements, got", freed)
+	}
+
+	// Freeing an empty list must be harmless.
+	var empty *glib.SList
+	empty.FreeFull(func(unsafe.Pointer) {
+		t.Error("Expected no elements to be freed")
+	})
+}